@@ -89,8 +89,9 @@ type Data struct {
 
 // Body is the main data being sent. It can either be a message, an exception, or a crash report.
 type Body struct {
-	Telemetry *Telemetry `json:"telemetry,omitempty"`
-	Trace     *Trace     `json:"trace,omitempty"`
+	// Telemetry is the list of telemetry events which occurred before this occurrence, oldest first.
+	Telemetry []*Telemetry `json:"telemetry,omitempty"`
+	Trace     *Trace       `json:"trace,omitempty"`
 	// TraceChain is the used for exceptions with inner exceptions or causes.
 	TraceChain  []*Trace     `json:"trace_chain,omitempty"`
 	Message     *Message     `json:"message,omitempty"`
@@ -106,7 +107,7 @@ type Telemetry struct {
 	// Source is the source of the telemetry data. Usually "client" or "server".
 	Source string `json:"source"`
 	// TimestampMs is the when this occurred, as a unix timestamp in milliseconds.
-	TimestampMs int           `json:"timestamp_ms"`
+	TimestampMs int64         `json:"timestamp_ms"`
 	Body        TelemetryBody `json:"body"`
 }
 
@@ -121,13 +122,29 @@ type Telemetry struct {
 // If type above is "navigation", body should contain "from" and "to" keys.
 //
 // If type above is "error", body should contain "message" key.
+//
+// The keys which are not used by the type are omitted.
 type TelemetryBody struct {
-	EndTimestampMs   int    `json:"end_timestamp_ms"`
-	Method           string `json:"method"`
-	StartTimestampMs int    `json:"start_timestamp_ms"`
-	StatusCode       string `json:"status_code"`
-	Subtype          string `json:"subtype"`
-	URL              string `json:"url"`
+	// Message is the log or error message.
+	Message string `json:"message,omitempty"`
+	// Subtype is the subtype of network event, such as "http".
+	Subtype string `json:"subtype,omitempty"`
+	// Method is the network request method.
+	Method string `json:"method,omitempty"`
+	// URL is the network request URL.
+	URL string `json:"url,omitempty"`
+	// StatusCode is the network response status code.
+	StatusCode string `json:"status_code,omitempty"`
+	// StartTimestampMs is the when the network request started, as a unix timestamp in milliseconds.
+	StartTimestampMs int64 `json:"start_timestamp_ms,omitempty"`
+	// EndTimestampMs is the when the network request ended, as a unix timestamp in milliseconds.
+	EndTimestampMs int64 `json:"end_timestamp_ms,omitempty"`
+	// Element is the dom element.
+	Element string `json:"element,omitempty"`
+	// From is the navigation source.
+	From string `json:"from,omitempty"`
+	// To is the navigation destination.
+	To string `json:"to,omitempty"`
 }
 
 // Trace is the stack trace data.
@@ -188,6 +205,9 @@ type Frame struct {
 	// The actual object will be found in locals.
 	Keywordspec string  `json:"keywordspec,omitempty"`
 	Locals      *Locals `json:"locals,omitempty"`
+	// InApp reports whether the frame is in the application code, rather than in the
+	// standard library or a dependency module. It is not sent to rollbar.
+	InApp bool `json:"-"`
 }
 
 // Context Additional code before and after the "code" line.
@@ -294,4 +314,6 @@ type Notifier struct {
 	Name string `json:"name"`
 	// Version library version string.
 	Version string `json:"version"`
+	// Diagnostic is the diagnostic data of the library, such as the truncation of the payload.
+	Diagnostic map[string]interface{} `json:"diagnostic,omitempty"`
 }
@@ -0,0 +1,570 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The hand-written JSON encoders of the payload types. They produce the same
+// output as encoding/json, without the reflection. Only the custom data values
+// of the interface type fall back to encoding/json.
+
+// AppendJSON appends the JSON encoding of p to dst and returns the extended buffer.
+func (p *Payload) AppendJSON(dst []byte) ([]byte, error) {
+	return p.appendJSON(dst)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *Payload) MarshalJSON() ([]byte, error) { return p.appendJSON(nil) }
+
+// MarshalJSON implements json.Marshaler.
+func (d *Data) MarshalJSON() ([]byte, error) { return d.appendJSON(nil) }
+
+// MarshalJSON implements json.Marshaler.
+func (b *Body) MarshalJSON() ([]byte, error) { return b.appendJSON(nil) }
+
+// MarshalJSON implements json.Marshaler.
+func (t *Telemetry) MarshalJSON() ([]byte, error) { return t.appendJSON(nil), nil }
+
+// MarshalJSON implements json.Marshaler.
+func (t *Trace) MarshalJSON() ([]byte, error) { return t.appendJSON(nil) }
+
+// MarshalJSON implements json.Marshaler.
+func (f *Frame) MarshalJSON() ([]byte, error) { return f.appendJSON(nil) }
+
+// MarshalJSON implements json.Marshaler.
+func (r *Request) MarshalJSON() ([]byte, error) { return r.appendJSON(nil), nil }
+
+// UnmarshalJSON implements json.Unmarshaler. The numbers of the custom and
+// diagnostic data and the frame locals are decoded as json.Number, so that the
+// decoded data is encoded back without the loss of precision.
+func (d *Data) UnmarshalJSON(b []byte) error {
+	type data Data // without the methods
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode((*data)(d))
+}
+
+func (p *Payload) appendJSON(b []byte) ([]byte, error) {
+	if p == nil {
+		return append(b, "null"...), nil
+	}
+	b = append(b, '{')
+	b = appendKey(b, "access_token")
+	b = appendString(b, p.AccessToken)
+	b = appendKey(b, "data")
+	b, err := p.Data.appendJSON(b)
+	return append(b, '}'), err
+}
+
+func (d *Data) appendJSON(b []byte) ([]byte, error) {
+	if d == nil {
+		return append(b, "null"...), nil
+	}
+	var err error
+	b = append(b, '{')
+	b = appendKey(b, "environment")
+	b = appendString(b, d.Environment)
+	b = appendKey(b, "body")
+	if b, err = d.Body.appendJSON(b); err != nil {
+		return b, err
+	}
+	b = appendStringField(b, "level", d.Level)
+	if d.Timestamp != 0 {
+		b = appendKey(b, "timestamp")
+		b = strconv.AppendInt(b, d.Timestamp, 10)
+	}
+	b = appendStringField(b, "code_version", d.CodeVersion)
+	b = appendStringField(b, "platform", d.Platform)
+	b = appendStringField(b, "language", d.Language)
+	b = appendStringField(b, "framework", d.Framework)
+	b = appendStringField(b, "context", d.Context)
+	if d.Request != nil {
+		b = appendKey(b, "request")
+		b = d.Request.appendJSON(b)
+	}
+	if p := d.Person; p != nil {
+		b = appendKey(b, "person")
+		b = append(b, '{')
+		b = appendKey(b, "id")
+		b = appendString(b, p.ID)
+		b = appendStringField(b, "username", p.Username)
+		b = appendStringField(b, "email", p.Email)
+		b = append(b, '}')
+	}
+	if s := d.Server; s != nil {
+		b = appendKey(b, "server")
+		b = append(b, '{')
+		b = appendStringField(b, "host", s.Host)
+		b = appendStringField(b, "root", s.Root)
+		b = appendStringField(b, "branch", s.Branch)
+		b = appendStringField(b, "code_version", s.CodeVersion)
+		b = appendStringField(b, "sha", s.Sha)
+		b = append(b, '}')
+	}
+	if c := d.Client; c != nil {
+		b = appendKey(b, "client")
+		b = append(b, '{')
+		b = appendKey(b, "javascript")
+		b = c.Javascript.appendJSON(b)
+		b = append(b, '}')
+	}
+	if len(d.Custom) > 0 {
+		b = appendKey(b, "custom")
+		if b, err = appendObject(b, d.Custom); err != nil {
+			return b, err
+		}
+	}
+	b = appendStringField(b, "fingerprint", d.Fingerprint)
+	b = appendStringField(b, "title", d.Title)
+	b = appendStringField(b, "uuid", d.UUID)
+	if n := d.Notifier; n != nil {
+		b = appendKey(b, "notifier")
+		b = append(b, '{')
+		b = appendKey(b, "name")
+		b = appendString(b, n.Name)
+		b = appendKey(b, "version")
+		b = appendString(b, n.Version)
+		if len(n.Diagnostic) > 0 {
+			b = appendKey(b, "diagnostic")
+			if b, err = appendObject(b, n.Diagnostic); err != nil {
+				return b, err
+			}
+		}
+		b = append(b, '}')
+	}
+	return append(b, '}'), nil
+}
+
+func (body *Body) appendJSON(b []byte) ([]byte, error) {
+	if body == nil {
+		return append(b, "null"...), nil
+	}
+	b = append(b, '{')
+	if len(body.Telemetry) > 0 {
+		b = appendKey(b, "telemetry")
+		b = append(b, '[')
+		for i, t := range body.Telemetry {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = t.appendJSON(b)
+		}
+		b = append(b, ']')
+	}
+	var err error
+	if body.Trace != nil {
+		b = appendKey(b, "trace")
+		if b, err = body.Trace.appendJSON(b); err != nil {
+			return b, err
+		}
+	}
+	if len(body.TraceChain) > 0 {
+		b = appendKey(b, "trace_chain")
+		b = append(b, '[')
+		for i, t := range body.TraceChain {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if b, err = t.appendJSON(b); err != nil {
+				return b, err
+			}
+		}
+		b = append(b, ']')
+	}
+	if body.Message != nil {
+		b = appendKey(b, "message")
+		b = append(b, '{')
+		b = appendKey(b, "body")
+		b = appendString(b, body.Message.Body)
+		b = append(b, '}')
+	}
+	if body.CrashReport != nil {
+		b = appendKey(b, "crash_report")
+		b = append(b, '{')
+		b = appendKey(b, "raw")
+		b = appendString(b, body.CrashReport.Raw)
+		b = append(b, '}')
+	}
+	return append(b, '}'), nil
+}
+
+func (t *Telemetry) appendJSON(b []byte) []byte {
+	if t == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '{')
+	b = appendKey(b, "level")
+	b = appendString(b, t.Level)
+	b = appendKey(b, "type")
+	b = appendString(b, t.Type)
+	b = appendKey(b, "source")
+	b = appendString(b, t.Source)
+	b = appendKey(b, "timestamp_ms")
+	b = strconv.AppendInt(b, t.TimestampMs, 10)
+	b = appendKey(b, "body")
+	b = append(b, '{')
+	tb := &t.Body
+	b = appendStringField(b, "message", tb.Message)
+	b = appendStringField(b, "subtype", tb.Subtype)
+	b = appendStringField(b, "method", tb.Method)
+	b = appendStringField(b, "url", tb.URL)
+	b = appendStringField(b, "status_code", tb.StatusCode)
+	if tb.StartTimestampMs != 0 {
+		b = appendKey(b, "start_timestamp_ms")
+		b = strconv.AppendInt(b, tb.StartTimestampMs, 10)
+	}
+	if tb.EndTimestampMs != 0 {
+		b = appendKey(b, "end_timestamp_ms")
+		b = strconv.AppendInt(b, tb.EndTimestampMs, 10)
+	}
+	b = appendStringField(b, "element", tb.Element)
+	b = appendStringField(b, "from", tb.From)
+	b = appendStringField(b, "to", tb.To)
+	b = append(b, '}')
+	return append(b, '}')
+}
+
+func (t *Trace) appendJSON(b []byte) ([]byte, error) {
+	if t == nil {
+		return append(b, "null"...), nil
+	}
+	var err error
+	b = append(b, '{')
+	b = appendKey(b, "frames")
+	if t.Frames == nil {
+		b = append(b, "null"...)
+	} else {
+		b = append(b, '[')
+		for i, f := range t.Frames {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			if b, err = f.appendJSON(b); err != nil {
+				return b, err
+			}
+		}
+		b = append(b, ']')
+	}
+	b = appendKey(b, "exception")
+	if e := t.Exception; e == nil {
+		b = append(b, "null"...)
+	} else {
+		b = append(b, '{')
+		b = appendKey(b, "class")
+		b = appendString(b, e.Class)
+		b = appendKey(b, "description")
+		b = appendString(b, e.Description)
+		b = appendKey(b, "message")
+		b = appendString(b, e.Message)
+		b = append(b, '}')
+	}
+	return append(b, '}'), nil
+}
+
+func (f *Frame) appendJSON(b []byte) ([]byte, error) {
+	if f == nil {
+		return append(b, "null"...), nil
+	}
+	b = append(b, '{')
+	b = appendKey(b, "filename")
+	b = appendString(b, f.Filename)
+	if f.Lineno != 0 {
+		b = appendKey(b, "lineno")
+		b = strconv.AppendInt(b, int64(f.Lineno), 10)
+	}
+	if f.Colno != 0 {
+		b = appendKey(b, "colno")
+		b = strconv.AppendInt(b, int64(f.Colno), 10)
+	}
+	b = appendStringField(b, "method", f.Method)
+	b = appendStringField(b, "code", f.Code)
+	b = appendStringField(b, "class_name", f.ClassName)
+	if c := f.Context; c != nil {
+		b = appendKey(b, "context")
+		b = append(b, '{')
+		b = appendKey(b, "pre")
+		b = appendStrings(b, c.Pre)
+		b = appendKey(b, "post")
+		b = appendStrings(b, c.Post)
+		b = append(b, '}')
+	}
+	if len(f.Argspec) > 0 {
+		b = appendKey(b, "argspec")
+		b = appendStrings(b, f.Argspec)
+	}
+	b = appendStringField(b, "varargspec", f.Varargspec)
+	b = appendStringField(b, "keywordspec", f.Keywordspec)
+	if l := f.Locals; l != nil {
+		var err error
+		b = appendKey(b, "locals")
+		b = append(b, '{')
+		b = appendKey(b, "request")
+		b = appendString(b, l.Request)
+		b = appendKey(b, "user")
+		b = appendString(b, l.User)
+		b = appendKey(b, "args")
+		if b, err = appendArray(b, l.Args); err != nil {
+			return b, err
+		}
+		b = appendKey(b, "kwargs")
+		b = appendStringMap(b, l.Kwargs)
+		b = append(b, '}')
+	}
+	return append(b, '}'), nil
+}
+
+func (r *Request) appendJSON(b []byte) []byte {
+	if r == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '{')
+	b = appendKey(b, "url")
+	b = appendString(b, r.URL)
+	b = appendKey(b, "method")
+	b = appendString(b, r.Method)
+	b = appendKey(b, "headers")
+	b = appendStringsMap(b, r.Headers)
+	b = appendKey(b, "params")
+	if p := r.Params; p == nil {
+		b = append(b, "null"...)
+	} else {
+		b = append(b, '{')
+		b = appendKey(b, "controller")
+		b = appendString(b, p.Controller)
+		b = appendKey(b, "action")
+		b = appendString(b, p.Action)
+		b = append(b, '}')
+	}
+	b = appendKey(b, "GET")
+	b = appendStringsMap(b, r.GET)
+	b = appendKey(b, "query_string")
+	b = appendString(b, r.QueryString)
+	b = appendKey(b, "POST")
+	b = appendStringsMap(b, r.POST)
+	b = appendKey(b, "body")
+	b = appendString(b, r.Body)
+	b = appendKey(b, "user_ip")
+	b = appendString(b, r.UserIP)
+	return append(b, '}')
+}
+
+func (j *Javascript) appendJSON(b []byte) []byte {
+	if j == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '{')
+	b = appendKey(b, "browser")
+	b = appendString(b, j.Browser)
+	b = appendKey(b, "code_version")
+	b = appendString(b, j.CodeVersion)
+	b = appendKey(b, "source_map_enabled")
+	b = strconv.AppendBool(b, j.SourceMapEnabled)
+	b = appendKey(b, "guess_uncaught_frames")
+	b = strconv.AppendBool(b, j.GuessUncaughtFrames)
+	return append(b, '}')
+}
+
+// appendKey appends the object key, preceded by a comma unless it is the first key.
+// The key must not need to be escaped.
+func appendKey(b []byte, key string) []byte {
+	if len(b) > 0 && b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	b = append(b, '"')
+	b = append(b, key...)
+	return append(b, '"', ':')
+}
+
+// appendStringField appends the omitempty string field.
+func appendStringField(b []byte, key, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendKey(b, key)
+	return appendString(b, s)
+}
+
+func appendStrings(b []byte, a []string) []byte {
+	if a == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '[')
+	for i, s := range a {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, s)
+	}
+	return append(b, ']')
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func appendStringMap(b []byte, m map[string]string) []byte {
+	if m == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, k)
+		b = append(b, ':')
+		b = appendString(b, m[k])
+	}
+	return append(b, '}')
+}
+
+func appendStringsMap(b []byte, m map[string][]string) []byte {
+	if m == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, k)
+		b = append(b, ':')
+		b = appendStrings(b, m[k])
+	}
+	return append(b, '}')
+}
+
+func appendObject(b []byte, m map[string]interface{}) ([]byte, error) {
+	if m == nil {
+		return append(b, "null"...), nil
+	}
+	var err error
+	b = append(b, '{')
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendString(b, k)
+		b = append(b, ':')
+		if b, err = appendValue(b, m[k]); err != nil {
+			return b, err
+		}
+	}
+	return append(b, '}'), nil
+}
+
+func appendArray(b []byte, a []interface{}) ([]byte, error) {
+	if a == nil {
+		return append(b, "null"...), nil
+	}
+	var err error
+	b = append(b, '[')
+	for i, v := range a {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if b, err = appendValue(b, v); err != nil {
+			return b, err
+		}
+	}
+	return append(b, ']'), nil
+}
+
+// appendValue appends the custom data value. The types other than the common
+// ones are encoded by encoding/json.
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...), nil
+	case string:
+		return appendString(b, v), nil
+	case bool:
+		return strconv.AppendBool(b, v), nil
+	case int:
+		return strconv.AppendInt(b, int64(v), 10), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case []string:
+		return appendStrings(b, v), nil
+	case map[string]string:
+		return appendStringMap(b, v), nil
+	case map[string]interface{}:
+		return appendObject(b, v)
+	case []interface{}:
+		return appendArray(b, v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return b, err
+	}
+	return append(b, data...), nil
+}
+
+const hex = "0123456789abcdef"
+
+// appendString appends the JSON string of s, escaped as encoding/json does,
+// including the HTML characters.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = utf8.AppendRune(b, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
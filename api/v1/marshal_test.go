@@ -0,0 +1,241 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// plain returns the copy of v whose struct types have no methods, so that
+// encoding/json encodes it by the reflection.
+func plain(v reflect.Value) reflect.Value {
+	t := plainType(v.Type())
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(plain(v.Elem()))
+		return p
+	case reflect.Struct:
+		s := reflect.New(t).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			s.Field(i).Set(plain(v.Field(i)))
+		}
+		return s
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		s := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(plain(v.Index(i)))
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func plainType(t reflect.Type) reflect.Type {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return reflect.PtrTo(plainType(t.Elem()))
+	case reflect.Slice:
+		return reflect.SliceOf(plainType(t.Elem()))
+	case reflect.Struct:
+		fields := make([]reflect.StructField, t.NumField())
+		for i := range fields {
+			fields[i] = t.Field(i)
+			fields[i].Type = plainType(fields[i].Type)
+		}
+		return reflect.StructOf(fields)
+	default:
+		return t
+	}
+}
+
+func testPayload() *Payload {
+	return &Payload{
+		AccessToken: "xxxxxxxxxxxxxxxx",
+		Data: &Data{
+			Environment: "production",
+			Body: &Body{
+				Telemetry: []*Telemetry{
+					{Level: "info", Type: "log", Source: "server", TimestampMs: 1497052800000, Body: TelemetryBody{Message: "started"}},
+					{Level: "error", Type: "network", Source: "server", TimestampMs: 1497052800001, Body: TelemetryBody{
+						Subtype: "http", Method: "GET", URL: "http://example.com/?q=<a&b>", StatusCode: "500",
+						StartTimestampMs: 1497052800001, EndTimestampMs: 1497052800002,
+					}},
+				},
+				Trace: &Trace{
+					Frames: []*Frame{
+						{Filename: "github.com/example/app/main.go", Lineno: 42, Method: "main.main", InApp: true},
+						{
+							Filename: "runtime/proc.go", Lineno: 271, Colno: 3, Method: "runtime.main", Code: "fn()",
+							ClassName: "runtime", Context: &Context{Pre: []string{"a"}},
+							Argspec: []string{"x"}, Varargspec: "args", Keywordspec: "kwargs",
+							Locals: &Locals{Args: []interface{}{1, "a", 1.5}, Kwargs: map[string]string{"b": "2", "a": "1"}},
+						},
+					},
+					Exception: &Exception{
+						Class:   "*errors.errorString",
+						Message: "quote \" backslash \\ control \x01\b\f\n\r\t html <>& invalid \xff line    日本語",
+					},
+				},
+				TraceChain:  []*Trace{{Exception: &Exception{Class: "cause"}}},
+				Message:     &Message{Body: "message"},
+				CrashReport: &CrashReport{Raw: "panic: test\n\ngoroutine 1 [running]:"},
+			},
+			Level:       "error",
+			Timestamp:   1497052800,
+			CodeVersion: "v1.0.0",
+			Platform:    "linux",
+			Language:    "go",
+			Context:     "project#index",
+			Request: &Request{
+				URL:     "http://example.com/",
+				Method:  "POST",
+				Headers: map[string][]string{"User-Agent": {"test"}, "Accept": {"*/*", "text/html"}},
+				POST:    map[string][]string{"name": {"gopher"}},
+				Body:    `{"name":"gopher"}`,
+				UserIP:  "$remote_ip",
+			},
+			Person: &Person{ID: "1", Email: "gopher@example.com"},
+			Server: &Server{Host: "localhost", Root: "github.com/example/app", Sha: "0123456789abcdef"},
+			Client: &Client{Javascript: &Javascript{Browser: "Mozilla", SourceMapEnabled: true}},
+			Custom: map[string]interface{}{
+				"string":  "s",
+				"int":     1,
+				"float":   1.5,
+				"int64":   int64(1<<62 + 1),
+				"bool":    true,
+				"nil":     nil,
+				"strings": []string{"a", "b"},
+				"map":     map[string]interface{}{"z": 1, "a": []interface{}{"x", 2}},
+				"struct":  struct{ A string }{A: "<a>"},
+			},
+			Fingerprint: "fingerprint",
+			Title:       "title",
+			UUID:        "6ba7b810-9dad-41d1-80b4-00c04fd430c8",
+			Notifier:    &Notifier{Name: "go-rollbar", Version: "0.0.0", Diagnostic: map[string]interface{}{"truncated": []string{"strings"}}},
+		},
+	}
+}
+
+func TestPayload_AppendJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload *Payload
+	}{
+		{name: "full", payload: testPayload()},
+		{name: "empty", payload: &Payload{Data: &Data{}}},
+		{name: "nil data", payload: &Payload{}},
+		{name: "empty body", payload: &Payload{Data: &Data{Body: &Body{Trace: &Trace{}}, Server: &Server{}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(plain(reflect.ValueOf(tt.payload)).Interface())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.payload.AppendJSON([]byte("prefix"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(got, []byte("prefix")) {
+				t.Fatalf("AppendJSON() = %s, want prefixed", got)
+			}
+			if got := got[len("prefix"):]; !bytes.Equal(got, want) {
+				t.Errorf("AppendJSON() =\n%s\nwant\n%s", got, want)
+			}
+
+			// encoding/json uses the marshalers
+			got, err = json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("json.Marshal() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestPayload_AppendJSON_error(t *testing.T) {
+	p := &Payload{Data: &Data{Custom: map[string]interface{}{"chan": make(chan int)}}}
+	if _, err := p.AppendJSON(nil); err == nil {
+		t.Error("AppendJSON() error = nil, want unsupported type error")
+	}
+}
+
+func benchmarkPayload() *Payload {
+	p := testPayload()
+	frames := make([]*Frame, 32)
+	for i := range frames {
+		frames[i] = &Frame{Filename: "github.com/example/app/" + strings.Repeat("x", i) + ".go", Lineno: i, Method: "app.F"}
+	}
+	p.Data.Body.Trace.Frames = frames
+	p.Data.Custom = map[string]interface{}{"user": "gopher", "count": 42}
+	return p
+}
+
+func BenchmarkPayload_AppendJSON(b *testing.B) {
+	p := benchmarkPayload()
+	b.ReportAllocs()
+	b.ResetTimer()
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = p.AppendJSON(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPayload_reflection(b *testing.B) {
+	p := plain(reflect.ValueOf(benchmarkPayload())).Interface()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPayload_roundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload *Payload
+	}{
+		{name: "full", payload: testPayload()},
+		{name: "empty", payload: &Payload{Data: &Data{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.payload.AppendJSON(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var p Payload
+			if err := json.Unmarshal(want, &p); err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.AppendJSON(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("round trip =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
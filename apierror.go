@@ -0,0 +1,138 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// maxErrorResponseSize is the upper limit of the error response body read.
+const maxErrorResponseSize = 64 << 10
+
+// The sentinel errors of the rollbar API responses. The *APIError matches them
+// by errors.Is with its status code. The 413 response matches ErrPayloadTooLarge.
+var (
+	// ErrUnauthorized is the 401 response, such as the missing or invalid access token.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is the 403 response, such as the disabled access token or
+	// the token without the post_server_item scope.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnprocessable is the 422 response, the payload is semantically invalid.
+	ErrUnprocessable = errors.New("unprocessable payload")
+	// ErrRateLimited is the 429 response, the project exceeded its rate limit.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is the error response of the rollbar API.
+type APIError struct {
+	// StatusCode is the HTTP status code, such as 401.
+	StatusCode int
+	// Status is the HTTP status, such as "401 Unauthorized".
+	Status string
+	// Err is the err code of the response body, 1 for the errors.
+	Err int
+	// Message is the error message of the response body, such as "invalid access token".
+	Message string
+	// RetryAfter is the duration to wait before retrying, if known.
+	RetryAfter time.Duration
+	// RateLimit is the rate limit of the project, if known.
+	RateLimit *RateLimit
+}
+
+// RateLimit is the rate limit state of the project sent in the X-Rate-Limit-* response headers.
+type RateLimit struct {
+	// Limit is the number of the occurrences allowed in the window.
+	Limit int
+	// Remaining is the number of the occurrences remaining in the window.
+	Remaining int
+	// Reset is the time the window resets.
+	Reset time.Time
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("received response: %s", e.Status)
+	}
+	return fmt.Sprintf("received response: %s: %s", e.Status, e.Message)
+}
+
+// Is reports whether the error matches the sentinel target by the status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrPayloadTooLarge:
+		return e.StatusCode == http.StatusRequestEntityTooLarge
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed if retried later.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// newAPIError creates the APIError from the response and its decoded body res, which may be nil.
+func newAPIError(resp *http.Response, res *api.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RateLimit:  parseRateLimit(resp.Header),
+	}
+	if res != nil {
+		e.Err, e.Message = res.Err, res.Message
+	}
+	e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if e.RetryAfter == 0 && e.StatusCode == http.StatusTooManyRequests {
+		if secs, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Remaining-Seconds")); err == nil && secs > 0 {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	return e
+}
+
+// parseRetryAfter parses the Retry-After header value, in seconds or HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// parseRateLimit parses the X-Rate-Limit-* headers, or returns nil if missing.
+func parseRateLimit(h http.Header) *RateLimit {
+	limit, err := strconv.Atoi(h.Get("X-Rate-Limit-Limit"))
+	if err != nil {
+		return nil
+	}
+	rl := &RateLimit{Limit: limit}
+	rl.Remaining, _ = strconv.Atoi(h.Get("X-Rate-Limit-Remaining"))
+	if reset, err := strconv.ParseInt(h.Get("X-Rate-Limit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+	}
+	return rl
+}
@@ -0,0 +1,143 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		header   map[string]string
+		body     string
+		sentinel error
+		want     *APIError
+	}{
+		{
+			name:     "unauthorized",
+			status:   http.StatusUnauthorized,
+			body:     `{"err":1,"message":"invalid access token"}`,
+			sentinel: ErrUnauthorized,
+			want: &APIError{
+				StatusCode: 401,
+				Status:     "401 Unauthorized",
+				Err:        1,
+				Message:    "invalid access token",
+			},
+		},
+		{
+			name:     "forbidden",
+			status:   http.StatusForbidden,
+			body:     `{"err":1,"message":"insufficient privileges: post_server_item scope is required but the access token only has post_client_item."}`,
+			sentinel: ErrForbidden,
+			want: &APIError{
+				StatusCode: 403,
+				Status:     "403 Forbidden",
+				Err:        1,
+				Message:    "insufficient privileges: post_server_item scope is required but the access token only has post_client_item.",
+			},
+		},
+		{
+			name:     "too large",
+			status:   http.StatusRequestEntityTooLarge,
+			body:     `<html>Request Entity Too Large</html>`,
+			sentinel: ErrPayloadTooLarge,
+			want: &APIError{
+				StatusCode: 413,
+				Status:     "413 Request Entity Too Large",
+			},
+		},
+		{
+			name:     "unprocessable",
+			status:   http.StatusUnprocessableEntity,
+			body:     `{"err":1,"message":"Invalid format. data.body.trace.frames is required."}`,
+			sentinel: ErrUnprocessable,
+			want: &APIError{
+				StatusCode: 422,
+				Status:     "422 Unprocessable Entity",
+				Err:        1,
+				Message:    "Invalid format. data.body.trace.frames is required.",
+			},
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{
+				"X-Rate-Limit-Limit":             "5000",
+				"X-Rate-Limit-Remaining":         "0",
+				"X-Rate-Limit-Reset":             "1500000000",
+				"X-Rate-Limit-Remaining-Seconds": "42",
+			},
+			body:     `{"err":1,"message":"project rate limit reached"}`,
+			sentinel: ErrRateLimited,
+			want: &APIError{
+				StatusCode: 429,
+				Status:     "429 Too Many Requests",
+				Err:        1,
+				Message:    "project rate limit reached",
+				RetryAfter: 42 * time.Second,
+				RateLimit:  &RateLimit{Limit: 5000, Remaining: 0, Reset: time.Unix(1500000000, 0)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cl := New("xxxxxxxxxxxxxxxx", WithEndpoint(server.URL))
+			_, err := cl.Error(errTest).Do(context.Background())
+
+			var got *APIError
+			if !errors.As(err, &got) {
+				t.Fatalf("Do() error = %#v, want *APIError", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Do() error = %#v, want %#v", got, tt.want)
+			}
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+			if tt.sentinel != ErrUnauthorized && errors.Is(err, ErrUnauthorized) {
+				t.Errorf("errors.Is(%v, ErrUnauthorized) = true", err)
+			}
+		})
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2017, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		v    string
+		want time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-1", 0},
+		{"Sat, 10 Jun 2017 12:00:30 GMT", 30 * time.Second},
+		{"Sat, 10 Jun 2017 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.v, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"runtime/debug"
+	"strconv"
+	"sync"
+)
+
+// buildInfo is the module and version control information embedded in the binary.
+type buildInfo struct {
+	goVersion   string
+	mainPackage string
+	path        string
+	version     string
+	revision    string
+	time        string
+	modified    bool
+	hasModified bool
+}
+
+var (
+	buildInfoOnce sync.Once
+	buildInfoData *buildInfo
+)
+
+// readBuildInfo returns the build information of the binary, or nil if it is
+// not available.
+func readBuildInfo() *buildInfo {
+	buildInfoOnce.Do(func() {
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		info := &buildInfo{
+			goVersion:   bi.GoVersion,
+			mainPackage: bi.Path,
+			path:        bi.Main.Path,
+			version:     bi.Main.Version,
+		}
+		if info.version == "(devel)" {
+			info.version = ""
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.revision = s.Value
+			case "vcs.time":
+				info.time = s.Value
+			case "vcs.modified":
+				info.modified, _ = strconv.ParseBool(s.Value)
+				info.hasModified = true
+			}
+		}
+		buildInfoData = info
+	})
+
+	return buildInfoData
+}
+
+// custom returns the build information sent as the "build" custom data.
+func (bi *buildInfo) custom() map[string]interface{} {
+	m := map[string]interface{}{
+		"go_version": bi.goVersion,
+	}
+	if bi.path != "" {
+		m["path"] = bi.path
+	}
+	if bi.version != "" {
+		m["version"] = bi.version
+	}
+	if bi.revision != "" {
+		m["vcs_revision"] = bi.revision
+	}
+	if bi.time != "" {
+		m["vcs_time"] = bi.time
+	}
+	if bi.hasModified {
+		m["vcs_modified"] = bi.modified
+	}
+	return m
+}
+
+// version returns the code version. It defaults to the VCS revision of the
+// binary, or the version of the main module.
+func (c *httpClient) version() string {
+	if c.codeVersion != "" || c.noBuildInfo {
+		return c.codeVersion
+	}
+	if bi := readBuildInfo(); bi != nil {
+		if bi.revision != "" {
+			return bi.revision
+		}
+		return bi.version
+	}
+	return ""
+}
+
+// buildCustom returns the custom data of the build information.
+func (c *httpClient) buildCustom() map[string]interface{} {
+	if c.noBuildInfo {
+		return nil
+	}
+	bi := readBuildInfo()
+	if bi == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"build": bi.custom(),
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_httpClient_version(t *testing.T) {
+	readBuildInfo() // initializes buildInfoData before overriding it
+	orig := buildInfoData
+	defer func() { buildInfoData = orig }()
+
+	tests := []struct {
+		name   string
+		client httpClient
+		info   *buildInfo
+		want   string
+	}{
+		{
+			name:   "revision",
+			client: httpClient{},
+			info:   &buildInfo{version: "v1.2.3", revision: "3da541559918a808c2402bba5012f6c60b27661c"},
+			want:   "3da541559918a808c2402bba5012f6c60b27661c",
+		},
+		{
+			name:   "module version",
+			client: httpClient{},
+			info:   &buildInfo{version: "v1.2.3"},
+			want:   "v1.2.3",
+		},
+		{
+			name:   "with CodeVersion",
+			client: httpClient{codeVersion: "2.1.12"},
+			info:   &buildInfo{version: "v1.2.3", revision: "3da541559918a808c2402bba5012f6c60b27661c"},
+			want:   "2.1.12",
+		},
+		{
+			name:   "without BuildInfo",
+			client: httpClient{noBuildInfo: true},
+			info:   &buildInfo{version: "v1.2.3", revision: "3da541559918a808c2402bba5012f6c60b27661c"},
+			want:   "",
+		},
+		{
+			name:   "no build info",
+			client: httpClient{},
+			info:   nil,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buildInfoData = tt.info
+			if got := tt.client.version(); got != tt.want {
+				t.Errorf("httpClient.version() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_httpClient_buildInfo_dump(t *testing.T) {
+	readBuildInfo() // initializes buildInfoData before overriding it
+	orig := buildInfoData
+	defer func() { buildInfoData = orig }()
+	buildInfoData = &buildInfo{goVersion: "go1.23.0", path: "example.com/wrapper", revision: "3da541559918a808c2402bba5012f6c60b27661c"}
+
+	d, err := ParseDump(strings.NewReader(testPanicDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.CodeVersion = "2.1.12"
+
+	tests := []struct {
+		name            string
+		err             error
+		wantCodeVersion string
+		wantBuild       bool
+	}{
+		{name: "error", err: errors.New("error"), wantCodeVersion: buildInfoData.revision, wantBuild: true},
+		{name: "dump", err: d, wantCodeVersion: "2.1.12", wantBuild: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultHTTPClient
+			data := c.payload(ErrorLevel, tt.err, nil).Data
+			if data.CodeVersion != tt.wantCodeVersion {
+				t.Errorf("CodeVersion = %q, want %q", data.CodeVersion, tt.wantCodeVersion)
+			}
+			if _, ok := data.Custom["build"]; ok != tt.wantBuild {
+				t.Errorf("Custom = %v, want build %v", data.Custom, tt.wantBuild)
+			}
+			if got := data.Server.Sha != ""; got != tt.wantBuild {
+				t.Errorf("Server.Sha = %q, want build %v", data.Server.Sha, tt.wantBuild)
+			}
+		})
+	}
+}
@@ -18,16 +18,22 @@ type Call interface {
 	Custom(map[string]interface{}) Call
 	UUID(string) Call
 	Title(string) Call
+	Fingerprint(string) Call
+	ID() string
 	Do(context.Context) (*api.Response, error)
 }
 
 type callOption struct {
 	err    error
+	pcs    []uintptr
 	req    *http.Request
 	person *api.Person
 	custom map[string]interface{}
 	id     string
 	title  string
+	fprint string
+
+	ignored error // IgnoredError if the error is ignored
 }
 
 func joinPayload(payload *api.Payload, opt callOption) {
@@ -38,7 +44,7 @@ func joinPayload(payload *api.Payload, opt callOption) {
 		payload.Data.Person = opt.person
 	}
 	if opt.custom != nil {
-		payload.Data.Custom = opt.custom
+		payload.Data.Custom = mergeCustom(payload.Data.Custom, opt.custom)
 	}
 	if opt.id != "" {
 		payload.Data.UUID = opt.id
@@ -46,6 +52,59 @@ func joinPayload(payload *api.Payload, opt callOption) {
 	if opt.title != "" {
 		payload.Data.Title = opt.title
 	}
+	if opt.fprint != "" {
+		payload.Data.Fingerprint = opt.fprint
+	}
+}
+
+// do creates the payload from opt and the error interfaces, and posts it to rollbar.
+func (c *httpClient) do(ctx context.Context, level Level, opt callOption) (*api.Response, error) {
+	if opt.ignored != nil {
+		return nil, opt.ignored
+	}
+
+	level = c.errorOptions(level, &opt)
+	payload := c.payload(level, opt.err, opt.pcs)
+	joinPayload(payload, opt)
+	if _, ok := opt.err.(*Dump); !ok { // the dump may be of another process
+		payload.Data.Body.Telemetry = c.telemetryEvents(ctx)
+		c.telemetry.Error(opt.err)
+	}
+
+	if err := validatePayload(payload); err != nil {
+		return nil, err
+	}
+
+	if c.recorder != nil {
+		if err := c.recorder.Record(payload); err != nil {
+			c.logger.Infof(ctx, "failed to record payload: %v\n", err)
+		}
+	}
+	return c.send(ctx, payload)
+}
+
+// errorOptions returns the level of the error by the class rules and the error
+// interfaces, and fills the options of opt which are not set by the call.
+func (c *httpClient) errorOptions(level Level, opt *callOption) Level {
+	if rule := c.errorClassRule(opt.err); rule != nil && rule.level != "" {
+		level = rule.level
+	}
+	return errorOptions(level, opt)
+}
+
+// mergeCustom returns the custom data of base overridden by custom.
+func mergeCustom(base, custom map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 {
+		return custom
+	}
+	m := make(map[string]interface{}, len(base)+len(custom))
+	for k, v := range base {
+		m[k] = v
+	}
+	for k, v := range custom {
+		m[k] = v
+	}
+	return m
 }
 
 // DebugCall represents a calls the debug level stack trace.
@@ -59,6 +118,10 @@ func (c *client) Debug(err error) Call {
 	var call DebugCall
 	call.client = c.debugClient
 	call.err = err
+	if call.ignored = call.client.ignore(err); call.ignored == nil {
+		call.pcs = callers(call.client.stackskip, call.client.stackDepth)
+		call.id = NewUUID()
+	}
 	return &call
 }
 
@@ -71,11 +134,9 @@ func (c *DebugCall) Request(req *http.Request) Call {
 // Person is the user affected by this event. Will be indexed by ID, username, and email.
 // People are stored in Rollbar keyed by ID. If you send a multiple different usernames/emails for the
 // same ID, the last received values will overwrite earlier ones.
+// The id is required. If it is empty or longer than 40 characters, the person is dropped
+// and the item is sent without it.
 func (c *DebugCall) Person(id, username, email string) Call {
-	if id == "" { // id is required
-		return c
-	}
-
 	c.person = &api.Person{
 		ID:       id,
 		Username: username,
@@ -96,14 +157,14 @@ func (c *DebugCall) Custom(custom map[string]interface{}) Call {
 // The UUID space is unique to each project, and can be used to look up an occurrence later.
 // It is also used to detect duplicate requests. If you send the same UUID in two payloads, the second
 // one will be discarded.
-// While optional, it is recommended that all clients generate and provide this field.
+// The call generates a random UUID4 unless it is given. See ID.
 func (c *DebugCall) UUID(id string) Call {
 	c.id = id
 	return c
 }
 
 // Title is an optional text description that is displayed when viewing an item.
-// It must be a string, of length 1-255 characters.
+// It must be a string, of length 1-255 characters. The longer title is truncated.
 // You can change the title in this configuration without impacting the fingerprint.
 // The new title will take effect if the item is reactivated after being resolved.
 func (c *DebugCall) Title(title string) Call {
@@ -111,17 +172,23 @@ func (c *DebugCall) Title(title string) Call {
 	return c
 }
 
+// Fingerprint overrides the fingerprint computed by the client Fingerprinter.
+// The occurrences which have the same fingerprint are grouped into the same item.
+func (c *DebugCall) Fingerprint(fingerprint string) Call {
+	c.fprint = fingerprint
+	return c
+}
+
+// ID returns the UUID of the occurrence, which is generated by the call unless
+// it is given by UUID. It is available before Do, and the same UUID is sent by
+// every Do of the call, so that rollbar discards the duplicates.
+func (c *DebugCall) ID() string {
+	return c.id
+}
+
 // Do executes the call to access rollbar endpoint.
 func (c *DebugCall) Do(ctx context.Context) (*api.Response, error) {
-	payload := c.client.payload(DebugLevel, c.err)
-	joinPayload(payload, c.callOption)
-	req, err := c.client.newRequest(payload)
-	if err != nil {
-		return nil, err
-	}
-	var m api.Response
-	err = c.client.Do(ctx, req, &m)
-	return &m, err
+	return c.client.do(ctx, DebugLevel, c.callOption)
 }
 
 // InfoCall represents a calls the info level stack trace.
@@ -135,6 +202,10 @@ func (c *client) Info(err error) Call {
 	var call InfoCall
 	call.client = c.infoClient
 	call.err = err
+	if call.ignored = call.client.ignore(err); call.ignored == nil {
+		call.pcs = callers(call.client.stackskip, call.client.stackDepth)
+		call.id = NewUUID()
+	}
 	return &call
 }
 
@@ -147,11 +218,9 @@ func (c *InfoCall) Request(req *http.Request) Call {
 // Person is the user affected by this event. Will be indexed by ID, username, and email.
 // People are stored in Rollbar keyed by ID. If you send a multiple different usernames/emails for the
 // same ID, the last received values will overwrite earlier ones.
+// The id is required. If it is empty or longer than 40 characters, the person is dropped
+// and the item is sent without it.
 func (c *InfoCall) Person(id, username, email string) Call {
-	if id == "" { // id is required
-		return c
-	}
-
 	c.person = &api.Person{
 		ID:       id,
 		Username: username,
@@ -172,14 +241,14 @@ func (c *InfoCall) Custom(custom map[string]interface{}) Call {
 // The UUID space is unique to each project, and can be used to look up an occurrence later.
 // It is also used to detect duplicate requests. If you send the same UUID in two payloads, the second
 // one will be discarded.
-// While optional, it is recommended that all clients generate and provide this field.
+// The call generates a random UUID4 unless it is given. See ID.
 func (c *InfoCall) UUID(id string) Call {
 	c.id = id
 	return c
 }
 
 // Title is an optional text description that is displayed when viewing an item.
-// It must be a string, of length 1-255 characters.
+// It must be a string, of length 1-255 characters. The longer title is truncated.
 // You can change the title in this configuration without impacting the fingerprint.
 // The new title will take effect if the item is reactivated after being resolved.
 func (c *InfoCall) Title(title string) Call {
@@ -187,17 +256,23 @@ func (c *InfoCall) Title(title string) Call {
 	return c
 }
 
+// Fingerprint overrides the fingerprint computed by the client Fingerprinter.
+// The occurrences which have the same fingerprint are grouped into the same item.
+func (c *InfoCall) Fingerprint(fingerprint string) Call {
+	c.fprint = fingerprint
+	return c
+}
+
+// ID returns the UUID of the occurrence, which is generated by the call unless
+// it is given by UUID. It is available before Do, and the same UUID is sent by
+// every Do of the call, so that rollbar discards the duplicates.
+func (c *InfoCall) ID() string {
+	return c.id
+}
+
 // Do executes the call to access rollbar endpoint.
 func (c *InfoCall) Do(ctx context.Context) (*api.Response, error) {
-	payload := c.client.payload(InfoLevel, c.err)
-	joinPayload(payload, c.callOption)
-	req, err := c.client.newRequest(payload)
-	if err != nil {
-		return nil, err
-	}
-	var m api.Response
-	err = c.client.Do(ctx, req, &m)
-	return &m, err
+	return c.client.do(ctx, InfoLevel, c.callOption)
 }
 
 // ErrorCall represents a calls the error level stack trace.
@@ -211,6 +286,10 @@ func (c *client) Error(err error) Call {
 	var call ErrorCall
 	call.client = c.errorClient
 	call.err = err
+	if call.ignored = call.client.ignore(err); call.ignored == nil {
+		call.pcs = callers(call.client.stackskip, call.client.stackDepth)
+		call.id = NewUUID()
+	}
 	return &call
 }
 
@@ -223,11 +302,9 @@ func (c *ErrorCall) Request(req *http.Request) Call {
 // Person is the user affected by this event. Will be indexed by ID, username, and email.
 // People are stored in Rollbar keyed by ID. If you send a multiple different usernames/emails for the
 // same ID, the last received values will overwrite earlier ones.
+// The id is required. If it is empty or longer than 40 characters, the person is dropped
+// and the item is sent without it.
 func (c *ErrorCall) Person(id, username, email string) Call {
-	if id == "" { // id is required
-		return c
-	}
-
 	c.person = &api.Person{
 		ID:       id,
 		Username: username,
@@ -248,14 +325,14 @@ func (c *ErrorCall) Custom(custom map[string]interface{}) Call {
 // The UUID space is unique to each project, and can be used to look up an occurrence later.
 // It is also used to detect duplicate requests. If you send the same UUID in two payloads, the second
 // one will be discarded.
-// While optional, it is recommended that all clients generate and provide this field.
+// The call generates a random UUID4 unless it is given. See ID.
 func (c *ErrorCall) UUID(id string) Call {
 	c.id = id
 	return c
 }
 
 // Title is an optional text description that is displayed when viewing an item.
-// It must be a string, of length 1-255 characters.
+// It must be a string, of length 1-255 characters. The longer title is truncated.
 // You can change the title in this configuration without impacting the fingerprint.
 // The new title will take effect if the item is reactivated after being resolved.
 func (c *ErrorCall) Title(title string) Call {
@@ -263,17 +340,23 @@ func (c *ErrorCall) Title(title string) Call {
 	return c
 }
 
+// Fingerprint overrides the fingerprint computed by the client Fingerprinter.
+// The occurrences which have the same fingerprint are grouped into the same item.
+func (c *ErrorCall) Fingerprint(fingerprint string) Call {
+	c.fprint = fingerprint
+	return c
+}
+
+// ID returns the UUID of the occurrence, which is generated by the call unless
+// it is given by UUID. It is available before Do, and the same UUID is sent by
+// every Do of the call, so that rollbar discards the duplicates.
+func (c *ErrorCall) ID() string {
+	return c.id
+}
+
 // Do executes the call to access rollbar endpoint.
 func (c *ErrorCall) Do(ctx context.Context) (*api.Response, error) {
-	payload := c.client.payload(ErrorLevel, c.err)
-	joinPayload(payload, c.callOption)
-	req, err := c.client.newRequest(payload)
-	if err != nil {
-		return nil, err
-	}
-	var m api.Response
-	err = c.client.Do(ctx, req, &m)
-	return &m, err
+	return c.client.do(ctx, ErrorLevel, c.callOption)
 }
 
 // WarnCall represents a calls the warning level stack trace.
@@ -287,6 +370,10 @@ func (c *client) Warn(err error) Call {
 	var call WarnCall
 	call.client = c.warnClient
 	call.err = err
+	if call.ignored = call.client.ignore(err); call.ignored == nil {
+		call.pcs = callers(call.client.stackskip, call.client.stackDepth)
+		call.id = NewUUID()
+	}
 	return &call
 }
 
@@ -299,11 +386,9 @@ func (c *WarnCall) Request(req *http.Request) Call {
 // Person is the user affected by this event. Will be indexed by ID, username, and email.
 // People are stored in Rollbar keyed by ID. If you send a multiple different usernames/emails for the
 // same ID, the last received values will overwrite earlier ones.
+// The id is required. If it is empty or longer than 40 characters, the person is dropped
+// and the item is sent without it.
 func (c *WarnCall) Person(id, username, email string) Call {
-	if id == "" { // id is required
-		return c
-	}
-
 	c.person = &api.Person{
 		ID:       id,
 		Username: username,
@@ -324,14 +409,14 @@ func (c *WarnCall) Custom(custom map[string]interface{}) Call {
 // The UUID space is unique to each project, and can be used to look up an occurrence later.
 // It is also used to detect duplicate requests. If you send the same UUID in two payloads, the second
 // one will be discarded.
-// While optional, it is recommended that all clients generate and provide this field.
+// The call generates a random UUID4 unless it is given. See ID.
 func (c *WarnCall) UUID(id string) Call {
 	c.id = id
 	return c
 }
 
 // Title is an optional text description that is displayed when viewing an item.
-// It must be a string, of length 1-255 characters.
+// It must be a string, of length 1-255 characters. The longer title is truncated.
 // You can change the title in this configuration without impacting the fingerprint.
 // The new title will take effect if the item is reactivated after being resolved.
 func (c *WarnCall) Title(title string) Call {
@@ -339,17 +424,23 @@ func (c *WarnCall) Title(title string) Call {
 	return c
 }
 
+// Fingerprint overrides the fingerprint computed by the client Fingerprinter.
+// The occurrences which have the same fingerprint are grouped into the same item.
+func (c *WarnCall) Fingerprint(fingerprint string) Call {
+	c.fprint = fingerprint
+	return c
+}
+
+// ID returns the UUID of the occurrence, which is generated by the call unless
+// it is given by UUID. It is available before Do, and the same UUID is sent by
+// every Do of the call, so that rollbar discards the duplicates.
+func (c *WarnCall) ID() string {
+	return c.id
+}
+
 // Do executes the call to access rollbar endpoint.
 func (c *WarnCall) Do(ctx context.Context) (*api.Response, error) {
-	payload := c.client.payload(WarnLevel, c.err)
-	joinPayload(payload, c.callOption)
-	req, err := c.client.newRequest(payload)
-	if err != nil {
-		return nil, err
-	}
-	var m api.Response
-	err = c.client.Do(ctx, req, &m)
-	return &m, err
+	return c.client.do(ctx, WarnLevel, c.callOption)
 }
 
 // CriticalCall represents a calls the critical level stack trace.
@@ -363,6 +454,10 @@ func (c *client) Critical(err error) Call {
 	var call CriticalCall
 	call.client = c.criticalClient
 	call.err = err
+	if call.ignored = call.client.ignore(err); call.ignored == nil {
+		call.pcs = callers(call.client.stackskip, call.client.stackDepth)
+		call.id = NewUUID()
+	}
 	return &call
 }
 
@@ -375,11 +470,9 @@ func (c *CriticalCall) Request(req *http.Request) Call {
 // Person is the user affected by this event. Will be indexed by ID, username, and email.
 // People are stored in Rollbar keyed by ID. If you send a multiple different usernames/emails for the
 // same ID, the last received values will overwrite earlier ones.
+// The id is required. If it is empty or longer than 40 characters, the person is dropped
+// and the item is sent without it.
 func (c *CriticalCall) Person(id, username, email string) Call {
-	if id == "" { // id is required
-		return c
-	}
-
 	c.person = &api.Person{
 		ID:       id,
 		Username: username,
@@ -400,14 +493,14 @@ func (c *CriticalCall) Custom(custom map[string]interface{}) Call {
 // The UUID space is unique to each project, and can be used to look up an occurrence later.
 // It is also used to detect duplicate requests. If you send the same UUID in two payloads, the second
 // one will be discarded.
-// While optional, it is recommended that all clients generate and provide this field.
+// The call generates a random UUID4 unless it is given. See ID.
 func (c *CriticalCall) UUID(id string) Call {
 	c.id = id
 	return c
 }
 
 // Title is an optional text description that is displayed when viewing an item.
-// It must be a string, of length 1-255 characters.
+// It must be a string, of length 1-255 characters. The longer title is truncated.
 // You can change the title in this configuration without impacting the fingerprint.
 // The new title will take effect if the item is reactivated after being resolved.
 func (c *CriticalCall) Title(title string) Call {
@@ -415,15 +508,21 @@ func (c *CriticalCall) Title(title string) Call {
 	return c
 }
 
+// Fingerprint overrides the fingerprint computed by the client Fingerprinter.
+// The occurrences which have the same fingerprint are grouped into the same item.
+func (c *CriticalCall) Fingerprint(fingerprint string) Call {
+	c.fprint = fingerprint
+	return c
+}
+
+// ID returns the UUID of the occurrence, which is generated by the call unless
+// it is given by UUID. It is available before Do, and the same UUID is sent by
+// every Do of the call, so that rollbar discards the duplicates.
+func (c *CriticalCall) ID() string {
+	return c.id
+}
+
 // Do executes the call to access rollbar endpoint.
 func (c *CriticalCall) Do(ctx context.Context) (*api.Response, error) {
-	payload := c.client.payload(CriticalLevel, c.err)
-	joinPayload(payload, c.callOption)
-	req, err := c.client.newRequest(payload)
-	if err != nil {
-		return nil, err
-	}
-	var m api.Response
-	err = c.client.Do(ctx, req, &m)
-	return &m, err
+	return c.client.do(ctx, CriticalLevel, c.callOption)
 }
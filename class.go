@@ -0,0 +1,53 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// classRule maps the errors which match the sentinel error, or have the type in
+// their chain, to the class and the default level.
+type classRule struct {
+	sentinel error
+	typ      reflect.Type
+	class    string
+	level    Level
+}
+
+func (r *classRule) match(err error) bool {
+	if r.typ == nil {
+		return errors.Is(err, r.sentinel)
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if reflect.TypeOf(err) == r.typ {
+			return true
+		}
+	}
+	return false
+}
+
+// errorClassRule returns the first rule which matches err.
+func (c *httpClient) errorClassRule(err error) *classRule {
+	if err == nil {
+		return nil
+	}
+	for i := range c.classRules {
+		if c.classRules[i].match(err) {
+			return &c.classRules[i]
+		}
+	}
+	return nil
+}
+
+// errorClass returns the class of err sent to rollbar.
+func (c *httpClient) errorClass(err error) string {
+	if rule := c.errorClassRule(err); rule != nil && rule.class != "" {
+		return rule.class
+	}
+	return errorClass(err, c.normalize)
+}
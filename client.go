@@ -22,12 +22,17 @@ import (
 )
 
 // Client represents a first Client methods.
+//
+// The level methods capture only the program counters of the caller. They are
+// resolved to the stack frames when the Call is done, so the Call can be done
+// in another goroutine without blocking the caller.
 type Client interface {
 	Debug(error) Call
 	Info(error) Call
 	Error(error) Call
 	Warn(error) Call
 	Critical(error) Call
+	Telemetry() *Telemetry
 }
 
 type client struct {
@@ -51,6 +56,28 @@ type httpClient struct {
 	serverRoot   string
 	serverBranch string
 	stackskip    int
+	stackDepth   int
+	crashOutput  string
+	noBuildInfo  bool
+
+	fingerprinter Fingerprinter
+	normalizer    Normalizer
+	classRules    []classRule
+	ignoreRules   []IgnoreRule
+
+	telemetrySize int
+	telemetry     *Telemetry
+
+	maxPayloadSize int
+	gzipThreshold  int
+
+	transport Transport
+	dryRun    *bool
+	recorder  *Recorder
+
+	// environmentSet and endpointSet record WithEnvironment and WithEndpoint, for the dry run default.
+	environmentSet bool
+	endpointSet    bool
 }
 
 var defaultHTTPClient = httpClient{
@@ -59,7 +86,10 @@ var defaultHTTPClient = httpClient{
 	logger:      nilLogger{},
 	environment: "development",
 	platform:    runtime.GOOS,
-	stackskip:   3, // default is 3
+
+	telemetrySize: DefaultTelemetrySize,
+
+	maxPayloadSize: DefaultMaxPayloadSize,
 }
 
 // Level level of stack trace.
@@ -82,6 +112,10 @@ const (
 //
 // The `token` is required, other optional parameters can be passed using the
 // various `With...` functions.
+//
+// If the ROLLBAR_DRY_RUN environment variable is true, or the "development"
+// environment is given by WithEnvironment without WithEndpoint, the items are
+// printed to os.Stderr instead of being sent. See WithDryRun.
 func New(token string, options ...Option) Client {
 	cl := defaultHTTPClient
 	cl.token = token
@@ -98,38 +132,83 @@ func New(token string, options ...Option) Client {
 	if cl.serverHost == "" {
 		cl.serverHost, _ = os.Hostname()
 	}
+	if cl.telemetrySize > 0 {
+		cl.telemetry = NewTelemetry(cl.telemetrySize)
+	}
+	dry := cl.dryRunDefault()
+	if cl.dryRun != nil {
+		dry = *cl.dryRun
+	}
+	if dry {
+		cl.transport = NewConsoleTransport(os.Stderr, isTerminal(os.Stderr))
+	}
 
-	return &client{
+	c := &client{
 		debugClient:    &cl,
 		infoClient:     &cl,
 		errorClient:    &cl,
 		warnClient:     &cl,
 		criticalClient: &cl,
 	}
+	if cl.crashOutput != "" {
+		cl.captureCrash(c)
+	}
+
+	return c
 }
 
-// payload creates the rollbar payload data.
-func (c *httpClient) payload(level Level, err error) *api.Payload {
+// Telemetry returns the telemetry events recorded by the client, which are
+// attached to every reported item. It returns nil if the telemetry is disabled.
+func (c *client) Telemetry() *Telemetry {
+	return c.errorClient.telemetry
+}
+
+// payload creates the rollbar payload data. The stack is resolved from the
+// program counters captured by callers.
+func (c *httpClient) payload(level Level, err error, pcs []uintptr) *api.Payload {
 	title := "<nil>"
 	if err != nil {
 		title = err.Error()
 	}
-	stack := CreateStack(c.stackskip)
+	stack := callerStack(pcs, c.stackskip, c.stackDepth)
+	timestamp, codeVersion, custom := time.Now(), c.version(), c.buildCustom()
+	d, isDump := err.(*Dump)
+	if isDump {
+		// the dump is of another process, such as the crashed one or the wrapped
+		// command, so the build information of this binary does not apply to it
+		stack, codeVersion, custom = d.Stack(), c.codeVersion, nil
+		if !d.Time.IsZero() {
+			timestamp = d.Time
+		}
+		if d.CodeVersion != "" {
+			codeVersion = d.CodeVersion
+		}
+	}
+
+	body := errorBody(err, stack, c.normalize)
+	var class, message string
+	if body.Trace != nil {
+		if rule := c.errorClassRule(err); rule != nil && rule.class != "" {
+			body.Trace.Exception.Class = rule.class
+		}
+		class, message = body.Trace.Exception.Class, c.normalize(body.Trace.Exception.Message)
+	}
 
 	data := &api.Data{
 		Environment: c.environment,
-		Body:        errorBody(err, stack),
+		Body:        body,
 		Level:       string(level),
-		Timestamp:   time.Now().Unix(),
+		Timestamp:   timestamp.Unix(),
 		Platform:    c.platform,
-		CodeVersion: c.codeVersion,
+		CodeVersion: codeVersion,
 		Language:    language,
 		Server: &api.Server{
 			Host:   c.serverHost,
 			Root:   c.serverRoot,
 			Branch: c.serverBranch,
 		},
-		Fingerprint: stack.Fingerprint(),
+		Custom:      custom,
+		Fingerprint: c.fingerprint(class, message, stack),
 		Title:       title,
 		Notifier: &api.Notifier{
 			Name:    Name,
@@ -137,6 +216,14 @@ func (c *httpClient) payload(level Level, err error) *api.Payload {
 		},
 	}
 
+	if bi := readBuildInfo(); bi != nil && !c.noBuildInfo && !isDump {
+		data.Server.Sha = bi.revision
+		data.Server.CodeVersion = codeVersion
+		if data.Server.Root == "" {
+			data.Server.Root = bi.path // the filenames are relative to the module
+		}
+	}
+
 	return &api.Payload{
 		AccessToken: c.token,
 		Data:        data,
@@ -145,15 +232,31 @@ func (c *httpClient) payload(level Level, err error) *api.Payload {
 
 // newRequest creates new http.Request from payload.
 func (c *httpClient) newRequest(payload *api.Payload) (*http.Request, error) {
-	if c.token == "" {
+	if payload.AccessToken == "" {
 		return nil, errors.New("empty token")
 	}
 
-	data, err := json.Marshal(payload)
+	// The payload is encoded in the pooled buffer, and copied to the body. The body
+	// is not pooled, since http.Client may read it after closing it, such as in the
+	// write loop racing with the response, or on the redirect.
+	buf := getBuffer()
+	defer putBuffer(buf)
+	data, err := marshalPayload(*buf, payload, c.maxPayloadSize)
+	*buf = data
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encode payload")
 	}
 
+	compress := c.gzipThreshold > 0 && len(data) >= c.gzipThreshold
+	if compress {
+		data, err = appendGzip(nil, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to compress payload")
+		}
+	} else {
+		data = append([]byte(nil), data...)
+	}
+
 	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(data))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create new POST request")
@@ -161,13 +264,19 @@ func (c *httpClient) newRequest(payload *api.Payload) (*http.Request, error) {
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", UserAgent)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 
 	return req, nil
 }
 
 // Do posts payload to rollbar.
-// The returns rollbar response into res.
+// The returns rollbar response into res. The error response is returned as *APIError.
 func (c *httpClient) Do(ctx context.Context, req *http.Request, res *api.Response) error {
+	// marks the request not to be recorded by RoundTripper, which may be used by c.client.
+	ctx = context.WithValue(ctx, roundTripKey{}, true)
+
 	resp, err := ctxhttp.Do(ctx, c.client, req)
 	if err != nil {
 		select {
@@ -184,29 +293,41 @@ func (c *httpClient) Do(ctx context.Context, req *http.Request, res *api.Respons
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.Errorf("received response: %s", resp.Status)
+		if err := c.parseResponse(ctx, io.LimitReader(resp.Body, maxErrorResponseSize), res); err != nil {
+			return newAPIError(resp, nil)
+		}
+		return newAPIError(resp, res)
 	}
 
-	return c.parseResponse(ctx, resp.Body, res)
+	if err := c.parseResponse(ctx, resp.Body, res); err != nil {
+		return err
+	}
+	if res.Err != 0 {
+		return newAPIError(resp, res)
+	}
+	return nil
 }
 
 // parseResponse parses the rollbar API response.
 func (c *httpClient) parseResponse(ctx context.Context, rdr io.Reader, resp *api.Response) error {
-	if c.debug {
-		buf := new(bytes.Buffer)
-		io.Copy(buf, rdr)
-
-		c.logger.Debugf(ctx, "-----> %s (response)\n", c.endpoint)
-		var m map[string]interface{}
-		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
-			c.logger.Debugf(ctx, "failed to unmarshal payload: %v", err)
-		} else {
-			formatted, _ := json.MarshalIndent(m, "", "  ")
-			c.logger.Debugf(ctx, "%s\n", formatted)
-		}
-		c.logger.Debugf(ctx, "<----- %s (response)\n", c.endpoint)
-		rdr = buf
+	if !c.debug {
+		return json.NewDecoder(rdr).Decode(resp)
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := io.Copy(appendWriter{buf}, rdr); err != nil {
+		return err
+	}
+
+	c.logger.Debugf(ctx, "-----> %s (response)\n", c.endpoint)
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, *buf, "", "  "); err != nil {
+		c.logger.Debugf(ctx, "failed to unmarshal payload: %v", err)
+	} else {
+		c.logger.Debugf(ctx, "%s\n", formatted.Bytes())
 	}
+	c.logger.Debugf(ctx, "<----- %s (response)\n", c.endpoint)
 
-	return json.NewDecoder(rdr).Decode(resp)
+	return json.Unmarshal(*buf, resp)
 }
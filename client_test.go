@@ -52,6 +52,7 @@ func TestNew(t *testing.T) {
 		t.Errorf("failed get the OS hostname: %v", err)
 	}
 	dl := dummyLogger{log.New(ioutil.Discard, "", 0)}
+	dryRunOff := false
 
 	type args struct {
 		token   string
@@ -75,7 +76,11 @@ func TestNew(t *testing.T) {
 				environment: "development",
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -92,7 +97,11 @@ func TestNew(t *testing.T) {
 				environment: "development",
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -109,7 +118,13 @@ func TestNew(t *testing.T) {
 				environment: "development",
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
+
+				endpointSet: true,
 			},
 		},
 		{
@@ -126,7 +141,11 @@ func TestNew(t *testing.T) {
 				environment: "development",
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -144,7 +163,11 @@ func TestNew(t *testing.T) {
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
 				debug:       true,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -161,7 +184,13 @@ func TestNew(t *testing.T) {
 				environment: "production",
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
+
+				environmentSet: true,
 			},
 		},
 		{
@@ -178,7 +207,11 @@ func TestNew(t *testing.T) {
 				environment: "development",
 				platform:    "google-app-engine",
 				serverHost:  hostName,
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -196,7 +229,11 @@ func TestNew(t *testing.T) {
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
 				codeVersion: "2.1.12",
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -213,7 +250,11 @@ func TestNew(t *testing.T) {
 				environment: "development",
 				platform:    runtime.GOOS,
 				serverHost:  "localhost",
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -231,7 +272,11 @@ func TestNew(t *testing.T) {
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
 				serverRoot:  "/app/src",
-				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
@@ -249,14 +294,18 @@ func TestNew(t *testing.T) {
 				platform:     runtime.GOOS,
 				serverHost:   hostName,
 				serverBranch: "test-branch",
-				stackskip:    3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
 			},
 		},
 		{
-			name: "with WithStackSkip",
+			name: "with WithCallerSkip",
 			args: args{
 				token:   testToken,
-				options: []Option{WithStackSkip(3)},
+				options: []Option{WithCallerSkip(3)},
 			},
 			wantClient: httpClient{
 				token:       testToken,
@@ -267,6 +316,56 @@ func TestNew(t *testing.T) {
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
 				stackskip:   3,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
+			},
+		},
+		{
+			name: "with WithStackSkip",
+			args: args{
+				token:   testToken,
+				options: []Option{WithStackSkip(5)},
+			},
+			wantClient: httpClient{
+				token:       testToken,
+				client:      http.DefaultClient,
+				endpoint:    api.DefaultEndpoint,
+				logger:      nilLogger{},
+				environment: "development",
+				platform:    runtime.GOOS,
+				serverHost:  hostName,
+				stackskip:   2,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
+			},
+		},
+		{
+			name: "with DryRun",
+			args: args{
+				token:   testToken,
+				options: []Option{WithDryRun(false)},
+			},
+			wantClient: httpClient{
+				token:       testToken,
+				client:      http.DefaultClient,
+				endpoint:    api.DefaultEndpoint,
+				logger:      nilLogger{},
+				environment: "development",
+				platform:    runtime.GOOS,
+				serverHost:  hostName,
+
+				telemetrySize: DefaultTelemetrySize,
+				telemetry:     NewTelemetry(DefaultTelemetrySize),
+
+				maxPayloadSize: DefaultMaxPayloadSize,
+
+				dryRun: &dryRunOff,
 			},
 		},
 	}
@@ -319,7 +418,6 @@ func Test_httpClient_payload(t *testing.T) {
 				environment: "development",
 				platform:    runtime.GOOS,
 				serverHost:  hostName,
-				stackskip:   3,
 			},
 			args: args{
 				level: DebugLevel,
@@ -330,7 +428,16 @@ func Test_httpClient_payload(t *testing.T) {
 				Data: &api.Data{
 					Environment: "development",
 					Body: &api.Body{
-						Trace: testTrace,
+						Trace: &api.Trace{
+							Frames: []*api.Frame{
+								{Method: "testing.tRunner"},
+								{Method: "runtime.goexit"},
+							},
+							Exception: &api.Exception{
+								Class:   "{23d90530}", // the anonymous error class of the message
+								Message: "default error",
+							},
+						},
 					},
 					Level:     "debug",
 					Timestamp: now,
@@ -339,8 +446,7 @@ func Test_httpClient_payload(t *testing.T) {
 					Server: &api.Server{
 						Host: hostName,
 					},
-					Fingerprint: testFingerprint,
-					Title:       "default error",
+					Title: "default error",
 					Notifier: &api.Notifier{
 						Name:    "go-rollbar",
 						Version: "0.0.0",
@@ -360,10 +466,57 @@ func Test_httpClient_payload(t *testing.T) {
 				serverRoot:   tt.fields.serverRoot,
 				serverBranch: tt.fields.serverBranch,
 				stackskip:    tt.fields.stackskip,
+				noBuildInfo:  true,
 			}
-			if got := c.payload(tt.args.level, tt.args.err); !reflect.DeepEqual(got, tt.want) {
+			pcs := callers(c.stackskip, c.stackDepth)
+			got := c.payload(tt.args.level, tt.args.err, pcs)
+
+			// the filenames and line numbers of the testing and runtime frames, and so
+			// the fingerprint, depend on the Go version and the architecture
+			if fingerprint := callerStack(pcs, c.stackskip, c.stackDepth).Fingerprint(); got.Data.Fingerprint != fingerprint {
+				t.Errorf("httpClient.payload().Data.Fingerprint = %q, want %q", got.Data.Fingerprint, fingerprint)
+			}
+			got.Data.Fingerprint = ""
+			for _, frame := range got.Data.Body.Trace.Frames {
+				frame.Filename, frame.Lineno = "", 0
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("httpClient.payload(%v, %v) = %v, want %v", tt.args.level, tt.args.err, got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_httpClient_newRequest_body(t *testing.T) {
+	c := defaultHTTPClient
+	c.noBuildInfo = true
+	payload := c.payload(ErrorLevel, errors.New("first"), nil)
+	payload.AccessToken = "first-token"
+	req, err := c.newRequest(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body.Close() // the transport closes the body, and may read it again
+
+	other := c.payload(ErrorLevel, errors.New("second"), nil)
+	other.AccessToken = "second-token"
+	if _, err := c.newRequest(other); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
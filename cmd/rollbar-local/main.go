@@ -0,0 +1,150 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command rollbar-local is a local stand-in of the rollbar API for the
+// development and the demos without a rollbar account.
+//
+// It implements the /api/1/item/ endpoint with the rollbar response schema,
+// groups the occurrences into the items by the environment and the fingerprint,
+// and keeps them in memory. The web UI at / lists the items and shows their
+// occurrences with the frames, request, person and custom data.
+//
+// Point the client at it:
+//
+//	rollbar.New(token, rollbar.WithEndpoint("http://localhost:8642/api/1/item/"))
+//
+// The items are sent to it in the "development" environment too, since the
+// client does not print them to the console instead when WithEndpoint is given.
+// Only ROLLBAR_DRY_RUN=1 or rollbar.WithDryRun(true) keeps them from it.
+//
+// Usage:
+//
+//	rollbar-local [flags]
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+var (
+	flagAddr           = flag.String("addr", "localhost:8642", "address to listen on")
+	flagToken          = flag.String("token", "", "access token to accept (default any non-empty token)")
+	flagMaxOccurrences = flag.Int("max-occurrences", 100, "number of the latest occurrences kept per item")
+	flagMaxSize        = flag.Int64("max-size", 1<<20, "upper limit of the payload size in bytes")
+	flagQuiet          = flag.Bool("quiet", false, "do not log the received items")
+)
+
+// itemPath is the path of the item endpoint.
+const itemPath = "/api/1/item/"
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetPrefix("rollbar-local: ")
+	log.SetFlags(log.LstdFlags)
+
+	s := newStore(*flagMaxOccurrences)
+	mux := http.NewServeMux()
+	mux.Handle(itemPath, &itemHandler{store: s, token: *flagToken, maxSize: *flagMaxSize})
+	mux.Handle(strings.TrimSuffix(itemPath, "/"), &itemHandler{store: s, token: *flagToken, maxSize: *flagMaxSize})
+	mux.Handle("/", newUI(s))
+
+	log.Printf("listening on http://%s/", *flagAddr)
+	log.Printf("send items with rollbar.WithEndpoint(%q)", "http://"+*flagAddr+itemPath)
+	log.Fatal(http.ListenAndServe(*flagAddr, mux))
+}
+
+// itemHandler implements the item endpoint of the rollbar API.
+type itemHandler struct {
+	store   *store
+	token   string
+	maxSize int64
+}
+
+func (h *itemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, "invalid gzip body")
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(body, h.maxSize+1))
+	switch {
+	case err != nil:
+		writeResponse(w, http.StatusBadRequest, "failed to read body")
+		return
+	case int64(len(b)) > h.maxSize:
+		writeResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("payload exceeds %d bytes", h.maxSize))
+		return
+	}
+
+	var payload api.Payload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		writeResponse(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	switch {
+	case payload.AccessToken == "":
+		writeResponse(w, http.StatusUnauthorized, "missing access token")
+		return
+	case h.token != "" && payload.AccessToken != h.token:
+		writeResponse(w, http.StatusUnauthorized, "invalid access token")
+		return
+	case payload.Data == nil:
+		writeResponse(w, http.StatusUnprocessableEntity, "missing data")
+		return
+	case payload.Data.Environment == "":
+		writeResponse(w, http.StatusUnprocessableEntity, "missing data.environment")
+		return
+	}
+
+	o := &Occurrence{
+		UUID:     payload.Data.UUID,
+		Received: time.Now(),
+		Data:     payload.Data,
+	}
+	if o.UUID == "" {
+		o.UUID = rollbar.NewUUID()
+	}
+	it := h.store.add(o)
+	if !*flagQuiet {
+		log.Printf("%s item #%d (%d): %s", strings.ToUpper(o.Data.Level), it.ID, it.Count, it.Title)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&api.Response{Result: api.Result{UUID: o.UUID}})
+}
+
+// writeResponse writes the error response.
+func writeResponse(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&api.Response{Err: 1, Message: message})
+}
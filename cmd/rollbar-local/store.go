@@ -0,0 +1,215 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// levelRank orders the levels by the severity.
+var levelRank = map[string]int{
+	"debug":    0,
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+// Item is the group of the occurrences with the same environment and fingerprint.
+type Item struct {
+	ID          int
+	Environment string
+	Fingerprint string
+	Title       string
+	Class       string
+	Level       string
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Count       int
+
+	// Occurrences is the latest occurrences, the oldest first.
+	Occurrences []*Occurrence
+}
+
+// Occurrence is a received payload.
+type Occurrence struct {
+	UUID     string
+	Received time.Time
+	Data     *api.Data
+}
+
+// Time returns the time of the occurrence, or the received time if not given.
+func (o *Occurrence) Time() time.Time {
+	if o.Data.Timestamp == 0 {
+		return o.Received
+	}
+	return time.Unix(o.Data.Timestamp, 0)
+}
+
+type itemKey struct {
+	environment string
+	fingerprint string
+}
+
+// store keeps the items in memory.
+type store struct {
+	mu             sync.RWMutex
+	items          map[itemKey]*Item
+	byID           map[int]*Item
+	maxOccurrences int
+	lastID         int
+}
+
+func newStore(maxOccurrences int) *store {
+	return &store{
+		items:          make(map[itemKey]*Item),
+		byID:           make(map[int]*Item),
+		maxOccurrences: maxOccurrences,
+	}
+}
+
+// add adds the occurrence to its item, creating the item of its first occurrence.
+// It returns the copy of the item without the occurrences.
+func (s *store) add(o *Occurrence) Item {
+	d := o.Data
+	markInApp(d)
+	title, class := d.Title, ""
+	if d.Body != nil && d.Body.Trace != nil && d.Body.Trace.Exception != nil {
+		class = d.Body.Trace.Exception.Class
+		if title == "" {
+			title = d.Body.Trace.Exception.Message
+		}
+	}
+	if title == "" && d.Body != nil && d.Body.Message != nil {
+		title = d.Body.Message.Body
+	}
+	fingerprint := d.Fingerprint
+	if fingerprint == "" {
+		fingerprint = class + ": " + title
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := itemKey{environment: d.Environment, fingerprint: fingerprint}
+	it := s.items[key]
+	if it == nil {
+		s.lastID++
+		it = &Item{
+			ID:          s.lastID,
+			Environment: d.Environment,
+			Fingerprint: fingerprint,
+			Title:       title,
+			Class:       class,
+			Level:       d.Level, // the level of the first occurrence, as rollbar does
+			FirstSeen:   o.Time(),
+		}
+		s.items[key] = it
+		s.byID[it.ID] = it
+	}
+	it.Count++
+	if t := o.Time(); t.After(it.LastSeen) {
+		it.LastSeen = t
+	}
+	it.Occurrences = append(it.Occurrences, o)
+	if s.maxOccurrences > 0 && len(it.Occurrences) > s.maxOccurrences {
+		it.Occurrences = append(it.Occurrences[:0:0], it.Occurrences[len(it.Occurrences)-s.maxOccurrences:]...)
+	}
+	c := *it
+	c.Occurrences = nil
+	return c
+}
+
+// list returns the copies of the items, the last seen first, filtered by the
+// environment and the minimum level if not empty.
+func (s *store) list(environment, level string) []Item {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]Item, 0, len(s.items))
+	for _, it := range s.items {
+		if environment != "" && it.Environment != environment {
+			continue
+		}
+		if level != "" && levelRank[it.Level] < levelRank[level] {
+			continue
+		}
+		items = append(items, *it)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].LastSeen.Equal(items[j].LastSeen) {
+			return items[i].LastSeen.After(items[j].LastSeen)
+		}
+		return items[i].ID > items[j].ID
+	})
+	return items
+}
+
+// environments returns the sorted environment names of the items.
+func (s *store) environments() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var envs []string
+	for k := range s.items {
+		if !seen[k.environment] {
+			seen[k.environment] = true
+			envs = append(envs, k.environment)
+		}
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+// get returns the copy of the item of id.
+func (s *store) get(id int) (Item, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	it, ok := s.byID[id]
+	if !ok {
+		return Item{}, false
+	}
+	c := *it
+	c.Occurrences = append([]*Occurrence(nil), it.Occurrences...)
+	return c, true
+}
+
+// reset removes all the items.
+func (s *store) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = make(map[itemKey]*Item)
+	s.byID = make(map[int]*Item)
+}
+
+// markInApp sets Frame.InApp, which is not sent in the payload, of the frames
+// in the application code. The client sends the filenames of the application
+// code relative to the module path in the server root, such as
+// "github.com/example/app/handler.go".
+func markInApp(d *api.Data) {
+	if d.Body == nil {
+		return
+	}
+	var root string
+	if d.Server != nil && d.Server.Root != "" {
+		root = strings.TrimSuffix(d.Server.Root, "/") + "/"
+	}
+	traces := d.Body.TraceChain
+	if d.Body.Trace != nil {
+		traces = append([]*api.Trace{d.Body.Trace}, traces...)
+	}
+	for _, t := range traces {
+		for _, f := range t.Frames {
+			f.InApp = strings.HasPrefix(f.Method, "main.") || root != "" && strings.HasPrefix(f.Filename, root)
+		}
+	}
+}
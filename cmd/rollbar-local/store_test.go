@@ -0,0 +1,190 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+func traceData(environment, class, message string, timestamp int64) *api.Data {
+	return &api.Data{
+		Environment: environment,
+		Level:       "error",
+		Timestamp:   timestamp,
+		Body: &api.Body{
+			Trace: &api.Trace{Exception: &api.Exception{Class: class, Message: message}},
+		},
+	}
+}
+
+func Test_store_add(t *testing.T) {
+	type item struct {
+		ID          int
+		Environment string
+		Fingerprint string
+		Title       string
+		Level       string
+		Count       int
+		FirstSeen   int64
+		LastSeen    int64
+		UUIDs       []string
+	}
+
+	tests := []struct {
+		name           string
+		maxOccurrences int
+		data           []*api.Data
+		want           []item // by ID
+	}{
+		{
+			name: "grouped by class and title",
+			data: []*api.Data{
+				traceData("production", "*errors.errorString", "not found", 100),
+				traceData("production", "*errors.errorString", "not found", 300),
+				traceData("production", "*errors.errorString", "timeout", 200),
+			},
+			want: []item{
+				{ID: 1, Environment: "production", Fingerprint: "*errors.errorString: not found", Title: "not found", Level: "error", Count: 2, FirstSeen: 100, LastSeen: 300, UUIDs: []string{"0", "1"}},
+				{ID: 2, Environment: "production", Fingerprint: "*errors.errorString: timeout", Title: "timeout", Level: "error", Count: 1, FirstSeen: 200, LastSeen: 200, UUIDs: []string{"2"}},
+			},
+		},
+		{
+			name: "grouped by environment",
+			data: []*api.Data{
+				traceData("production", "*errors.errorString", "not found", 100),
+				traceData("staging", "*errors.errorString", "not found", 100),
+			},
+			want: []item{
+				{ID: 1, Environment: "production", Fingerprint: "*errors.errorString: not found", Title: "not found", Level: "error", Count: 1, FirstSeen: 100, LastSeen: 100, UUIDs: []string{"0"}},
+				{ID: 2, Environment: "staging", Fingerprint: "*errors.errorString: not found", Title: "not found", Level: "error", Count: 1, FirstSeen: 100, LastSeen: 100, UUIDs: []string{"1"}},
+			},
+		},
+		{
+			name: "grouped by fingerprint",
+			data: []*api.Data{
+				{Environment: "production", Level: "warning", Fingerprint: "user", Title: "user 1 not found", Timestamp: 100},
+				{Environment: "production", Level: "critical", Fingerprint: "user", Title: "user 2 not found", Timestamp: 50},
+			},
+			want: []item{
+				{ID: 1, Environment: "production", Fingerprint: "user", Title: "user 1 not found", Level: "warning", Count: 2, FirstSeen: 100, LastSeen: 100, UUIDs: []string{"0", "1"}},
+			},
+		},
+		{
+			name: "message",
+			data: []*api.Data{
+				{Environment: "production", Level: "info", Timestamp: 100, Body: &api.Body{Message: &api.Message{Body: "started"}}},
+			},
+			want: []item{
+				{ID: 1, Environment: "production", Fingerprint: ": started", Title: "started", Level: "info", Count: 1, FirstSeen: 100, LastSeen: 100, UUIDs: []string{"0"}},
+			},
+		},
+		{
+			name:           "trimmed",
+			maxOccurrences: 2,
+			data: []*api.Data{
+				traceData("production", "*errors.errorString", "not found", 100),
+				traceData("production", "*errors.errorString", "not found", 200),
+				traceData("production", "*errors.errorString", "not found", 300),
+			},
+			want: []item{
+				{ID: 1, Environment: "production", Fingerprint: "*errors.errorString: not found", Title: "not found", Level: "error", Count: 3, FirstSeen: 100, LastSeen: 300, UUIDs: []string{"1", "2"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(tt.maxOccurrences)
+			for i, d := range tt.data {
+				o := &Occurrence{UUID: string(rune('0' + i)), Received: time.Unix(0, 0), Data: d}
+				if got := s.add(o); got.Occurrences != nil {
+					t.Errorf("add() = %+v, want the item without the occurrences", got)
+				}
+			}
+
+			var got []item
+			for id := 1; ; id++ {
+				it, ok := s.get(id)
+				if !ok {
+					break
+				}
+				var uuids []string
+				for _, o := range it.Occurrences {
+					uuids = append(uuids, o.UUID)
+				}
+				got = append(got, item{
+					ID:          it.ID,
+					Environment: it.Environment,
+					Fingerprint: it.Fingerprint,
+					Title:       it.Title,
+					Level:       it.Level,
+					Count:       it.Count,
+					FirstSeen:   it.FirstSeen.Unix(),
+					LastSeen:    it.LastSeen.Unix(),
+					UUIDs:       uuids,
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_store_list(t *testing.T) {
+	s := newStore(0)
+	s.add(&Occurrence{Data: &api.Data{Environment: "production", Level: "error", Fingerprint: "a", Timestamp: 100}})
+	s.add(&Occurrence{Data: &api.Data{Environment: "production", Level: "info", Fingerprint: "b", Timestamp: 300}})
+	s.add(&Occurrence{Data: &api.Data{Environment: "staging", Level: "critical", Fingerprint: "c", Timestamp: 200}})
+
+	tests := []struct {
+		environment string
+		level       string
+		want        []string
+	}{
+		{want: []string{"b", "c", "a"}},
+		{environment: "production", want: []string{"b", "a"}},
+		{level: "error", want: []string{"c", "a"}},
+		{environment: "staging", level: "error", want: []string{"c"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, it := range s.list(tt.environment, tt.level) {
+			got = append(got, it.Fingerprint)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("list(%q, %q) = %v, want %v", tt.environment, tt.level, got, tt.want)
+		}
+	}
+	if got, want := s.environments(), []string{"production", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("environments() = %v, want %v", got, want)
+	}
+}
+
+func Test_markInApp(t *testing.T) {
+	frames := []*api.Frame{
+		{Filename: "github.com/example/app/handler.go", Method: "github.com/example/app.(*Server).ServeHTTP"},
+		{Filename: "github.com/example/app/cmd/app/main.go", Method: "main.main"},
+		{Filename: "github.com/example/application/db.go", Method: "github.com/example/application.Open"},
+		{Filename: "github.com/example/app@v1.0.0/handler.go", Method: "github.com/example/app.(*Server).ServeHTTP"},
+		{Filename: "net/http/server.go", Method: "net/http.serverHandler.ServeHTTP"},
+	}
+	d := &api.Data{
+		Server: &api.Server{Root: "github.com/example/app"},
+		Body:   &api.Body{TraceChain: []*api.Trace{{Frames: frames}}},
+	}
+	markInApp(d)
+
+	var got []bool
+	for _, f := range frames {
+		got = append(got, f.InApp)
+	}
+	if want := []bool{true, true, false, false, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InApp = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ui serves the web UI of the items.
+type ui struct {
+	store *store
+	mux   *http.ServeMux
+}
+
+func newUI(s *store) *ui {
+	u := &ui{store: s, mux: http.NewServeMux()}
+	u.mux.HandleFunc("/", u.serveList)
+	u.mux.HandleFunc("/items/", u.serveItem)
+	u.mux.HandleFunc("/reset", u.serveReset)
+	return u
+}
+
+func (u *ui) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mux.ServeHTTP(w, r)
+}
+
+func (u *ui) serveList(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	env, level := r.FormValue("environment"), r.FormValue("level")
+	render(w, listTemplate, map[string]interface{}{
+		"Items":        u.store.list(env, level),
+		"Environments": u.store.environments(),
+		"Environment":  env,
+		"Levels":       []string{"debug", "info", "warning", "error", "critical"},
+		"Level":        level,
+	})
+}
+
+func (u *ui) serveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/items/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	it, ok := u.store.get(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// the latest occurrence by default
+	i := len(it.Occurrences) - 1
+	if v := r.FormValue("occurrence"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n < len(it.Occurrences) {
+			i = n
+		}
+	}
+	var o *Occurrence
+	if i >= 0 {
+		o = it.Occurrences[i]
+	}
+
+	render(w, itemTemplate, map[string]interface{}{
+		"Item":       it,
+		"Occurrence": o,
+		"Index":      i,
+	})
+}
+
+func (u *ui) serveReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	u.store.reset()
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func render(w http.ResponseWriter, t *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := t.ExecuteTemplate(w, "page", data); err != nil {
+		log.Printf("failed to render page: %v", err)
+	}
+}
+
+var funcs = template.FuncMap{
+	"ago": func(t time.Time) string {
+		d := time.Since(t)
+		switch {
+		case d < time.Minute:
+			return strconv.Itoa(int(d/time.Second)) + "s ago"
+		case d < time.Hour:
+			return strconv.Itoa(int(d/time.Minute)) + "m ago"
+		case d < 24*time.Hour:
+			return strconv.Itoa(int(d/time.Hour)) + "h ago"
+		default:
+			return t.Format("2006-01-02 15:04")
+		}
+	},
+	"time": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05 MST")
+	},
+	"json": func(v interface{}) string {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err.Error()
+		}
+		return string(b)
+	},
+	"sortedKeys": func(m map[string][]string) []string {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	},
+	"join": strings.Join,
+	"reverse": func(a []*Occurrence) []int {
+		idx := make([]int, len(a))
+		for i := range idx {
+			idx[i] = len(a) - 1 - i
+		}
+		return idx
+	},
+}
+
+const layout = `{{define "page"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{template "title" .}} - rollbar-local</title>
+<style>
+body { font: 14px/1.4 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #222; }
+header { background: #2b2d42; color: #fff; padding: 10px 20px; display: flex; align-items: center; gap: 20px; }
+header a { color: #fff; text-decoration: none; font-weight: bold; }
+header form { margin-left: auto; }
+main { padding: 20px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eee; vertical-align: top; }
+th { background: #f6f6f8; font-weight: 600; }
+a { color: #3a56b0; }
+code, pre, .mono { font-family: SFMono-Regular, Menlo, Consolas, monospace; font-size: 12px; }
+pre { background: #f6f6f8; padding: 10px; overflow-x: auto; margin: 0; }
+h2 { margin-top: 28px; font-size: 16px; }
+.level { display: inline-block; padding: 1px 6px; border-radius: 3px; color: #fff; font-size: 11px; text-transform: uppercase; }
+.level-debug { background: #8d99ae; } .level-info { background: #3a86ff; } .level-warning { background: #f4a261; }
+.level-error { background: #e63946; } .level-critical { background: #7b2cbf; }
+.frames td { font-family: SFMono-Regular, Menlo, Consolas, monospace; font-size: 12px; padding: 3px 8px; }
+.frames tr.lib td { color: #999; }
+.frames tr.app td { font-weight: bold; }
+.muted { color: #888; }
+.occurrences a.current { font-weight: bold; color: #222; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 4px 16px; margin: 0; }
+dt { color: #888; }
+dd { margin: 0; }
+</style>
+</head>
+<body>
+<header>
+<a href="/">rollbar-local</a>
+<form method="post" action="/reset"><button>Clear all items</button></form>
+</header>
+<main>{{template "content" .}}</main>
+</body>
+</html>{{end}}`
+
+const listHTML = `{{define "title"}}Items{{end}}
+{{define "content"}}
+<form method="get">
+<select name="environment" onchange="this.form.submit()">
+<option value="">all environments</option>
+{{range .Environments}}<option{{if eq . $.Environment}} selected{{end}}>{{.}}</option>{{end}}
+</select>
+<select name="level" onchange="this.form.submit()">
+<option value="">all levels</option>
+{{range .Levels}}<option value="{{.}}"{{if eq . $.Level}} selected{{end}}>{{.}} and above</option>{{end}}
+</select>
+</form>
+{{if .Items}}
+<table>
+<tr><th>#</th><th>Level</th><th>Title</th><th>Environment</th><th>Occurrences</th><th>Last seen</th><th>First seen</th></tr>
+{{range .Items}}
+<tr>
+<td><a href="/items/{{.ID}}">{{.ID}}</a></td>
+<td><span class="level level-{{.Level}}">{{.Level}}</span></td>
+<td><a href="/items/{{.ID}}">{{.Title}}</a>{{if .Class}}<div class="muted mono">{{.Class}}</div>{{end}}</td>
+<td>{{.Environment}}</td>
+<td>{{.Count}}</td>
+<td title="{{time .LastSeen}}">{{ago .LastSeen}}</td>
+<td title="{{time .FirstSeen}}">{{ago .FirstSeen}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p class="muted">No items yet. Send one with <code>rollbar.WithEndpoint("http://HOST/api/1/item/")</code>.</p>
+{{end}}
+{{end}}`
+
+const itemHTML = `{{define "title"}}#{{.Item.ID}} {{.Item.Title}}{{end}}
+{{define "content"}}
+{{with .Item}}
+<h1><span class="level level-{{.Level}}">{{.Level}}</span> #{{.ID}} {{.Title}}</h1>
+<dl>
+<dt>environment</dt><dd>{{.Environment}}</dd>
+<dt>occurrences</dt><dd>{{.Count}}</dd>
+<dt>first seen</dt><dd>{{time .FirstSeen}}</dd>
+<dt>last seen</dt><dd>{{time .LastSeen}}</dd>
+<dt>fingerprint</dt><dd class="mono">{{.Fingerprint}}</dd>
+</dl>
+{{end}}
+
+<h2>Occurrences</h2>
+<div class="occurrences">
+{{$item := .Item}}{{$index := .Index}}
+{{range reverse .Item.Occurrences}}{{$o := index $item.Occurrences .}}
+<a href="?occurrence={{.}}"{{if eq . $index}} class="current"{{end}}>{{time $o.Time}}</a>{{if $o.Data.Level}} <span class="muted">{{$o.Data.Level}}</span>{{end}}<br>
+{{end}}
+{{if gt .Item.Count (len .Item.Occurrences)}}<p class="muted">{{len .Item.Occurrences}} latest of {{.Item.Count}} occurrences are kept.</p>{{end}}
+</div>
+
+{{with .Occurrence}}{{$d := .Data}}
+<h2>Occurrence {{.UUID}}</h2>
+<dl>
+<dt>time</dt><dd>{{time .Time}}</dd>
+<dt>received</dt><dd>{{time .Received}}</dd>
+{{if $d.CodeVersion}}<dt>code version</dt><dd>{{$d.CodeVersion}}</dd>{{end}}
+{{if $d.Platform}}<dt>platform</dt><dd>{{$d.Platform}}</dd>{{end}}
+{{if $d.Language}}<dt>language</dt><dd>{{$d.Language}}</dd>{{end}}
+{{if $d.Context}}<dt>context</dt><dd>{{$d.Context}}</dd>{{end}}
+{{with $d.Server}}{{if .Host}}<dt>server</dt><dd>{{.Host}}{{if .Root}} <span class="muted">{{.Root}}</span>{{end}}{{if .Branch}} ({{.Branch}}){{end}}{{if .Sha}} <span class="mono">{{.Sha}}</span>{{end}}</dd>{{end}}{{end}}
+{{with $d.Notifier}}<dt>notifier</dt><dd>{{.Name}} {{.Version}}</dd>{{end}}
+</dl>
+
+{{with $d.Body}}
+{{with .Trace}}{{template "trace" .}}{{end}}
+{{range .TraceChain}}<h2>Caused by</h2>{{template "trace" .}}{{end}}
+{{with .Message}}<h2>Message</h2><pre>{{.Body}}</pre>{{end}}
+{{with .CrashReport}}<h2>Crash report</h2><pre>{{.Raw}}</pre>{{end}}
+{{end}}
+
+{{with $d.Request}}
+<h2>Request</h2>
+<dl>
+<dt>{{.Method}}</dt><dd class="mono">{{.URL}}</dd>
+{{if .QueryString}}<dt>query</dt><dd class="mono">{{.QueryString}}</dd>{{end}}
+{{if .UserIP}}<dt>user IP</dt><dd>{{.UserIP}}</dd>{{end}}
+{{range $k := sortedKeys .Headers}}<dt>{{$k}}</dt><dd class="mono">{{join (index $.Occurrence.Data.Request.Headers $k) ", "}}</dd>{{end}}
+</dl>
+{{if .Body}}<pre>{{.Body}}</pre>{{end}}
+{{end}}
+
+{{with $d.Person}}
+<h2>Person</h2>
+<dl>
+<dt>id</dt><dd>{{.ID}}</dd>
+{{if .Username}}<dt>username</dt><dd>{{.Username}}</dd>{{end}}
+{{if .Email}}<dt>email</dt><dd>{{.Email}}</dd>{{end}}
+</dl>
+{{end}}
+
+{{with $d.Custom}}<h2>Custom</h2><pre>{{json .}}</pre>{{end}}
+
+{{with $d.Body}}{{with .Telemetry}}
+<h2>Telemetry</h2>
+<table>
+<tr><th>Level</th><th>Type</th><th>Source</th><th>Body</th></tr>
+{{range .}}<tr><td>{{.Level}}</td><td>{{.Type}}</td><td>{{.Source}}</td><td class="mono">{{with .Body.Message}}{{.}}{{else}}{{.Body.Method}} {{.Body.URL}} {{.Body.StatusCode}}{{end}}</td></tr>{{end}}
+</table>
+{{end}}{{end}}
+{{end}}
+{{end}}
+
+{{define "trace"}}
+{{with .Exception}}<h2>{{.Class}}</h2><pre>{{.Message}}</pre>{{if .Description}}<p>{{.Description}}</p>{{end}}{{end}}
+{{if .Frames}}
+<table class="frames">
+{{range .Frames}}<tr class="{{if .InApp}}app{{else}}lib{{end}}"><td>{{.Method}}</td><td>{{.Filename}}:{{.Lineno}}</td></tr>{{end}}
+</table>
+{{end}}
+{{end}}`
+
+var (
+	listTemplate = template.Must(template.Must(template.New("list").Funcs(funcs).Parse(layout)).Parse(listHTML))
+	itemTemplate = template.Must(template.Must(template.New("item").Funcs(funcs).Parse(layout)).Parse(itemHTML))
+)
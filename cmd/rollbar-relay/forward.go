@@ -0,0 +1,208 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+const (
+	// minBackoff and maxBackoff bound the wait after the temporary failure.
+	minBackoff = time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// forwarder forwards the queued payloads to rollbar in batches.
+type forwarder struct {
+	queue     *queue
+	transport rollbar.Transport
+	token     string        // the token of the payloads without one
+	batch     int           // the number of the payloads forwarded at once
+	interval  time.Duration // the minimum interval of the payloads, for the throttling
+	retries   int           // the maximum attempts of a payload rejected by rollbar
+	maxAge    time.Duration // the maximum age of a payload retried for the temporary failures
+	timeout   time.Duration // the timeout of a payload
+
+	attempts map[string]int
+	backoff  time.Duration
+}
+
+// run forwards the queued payloads until ctx is done.
+func (f *forwarder) run(ctx context.Context) {
+	f.attempts = make(map[string]int)
+	for {
+		names, err := f.queue.list(f.batch)
+		if err != nil {
+			log.Printf("failed to list queue: %v", err)
+		}
+		if len(names) == 0 {
+			select {
+			case <-f.queue.ready:
+				continue
+			case <-time.After(time.Minute): // the payloads queued by another relay process
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		start := time.Now()
+		wait := f.forwardBatch(ctx, names)
+		if ctx.Err() != nil {
+			return
+		}
+		if throttle := f.interval*time.Duration(len(names)) - time.Since(start); throttle > wait {
+			wait = throttle
+		}
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// forwardBatch forwards the payloads concurrently, and returns the wait before
+// the next batch, if rollbar is unavailable or rate limited.
+func (f *forwarder) forwardBatch(ctx context.Context, names []string) time.Duration {
+	results := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = f.forward(ctx, name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	var wait time.Duration
+	failed := false
+	for i, err := range results {
+		name := names[i]
+		if err == nil {
+			delete(f.attempts, name)
+			if err := f.queue.remove(name); err != nil {
+				log.Printf("failed to remove %s: %v", name, err)
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return 0
+		}
+
+		if temporary(err) {
+			if f.maxAge > 0 && time.Since(queuedAt(name)) > f.maxAge {
+				log.Printf("dropped %s queued for %v: %v", name, f.maxAge, err)
+				f.drop(name)
+				continue
+			}
+			log.Printf("failed to forward %s: %v", name, err)
+			failed = true
+			var apiErr *rollbar.APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+				wait = apiErr.RetryAfter
+			}
+			continue
+		}
+
+		// the rejection by rollbar, such as by the invalid token, may be fixed
+		// on its side, so it is retried up to the attempts
+		f.attempts[name]++
+		var perr permanentError
+		if errors.As(err, &perr) || f.attempts[name] >= f.retries {
+			log.Printf("dropped %s after %d attempts: %v", name, f.attempts[name], err)
+			f.drop(name)
+			continue
+		}
+		log.Printf("rejected %s (attempt %d): %v", name, f.attempts[name], err)
+		failed = true
+	}
+
+	if !failed {
+		f.backoff = 0
+		return wait
+	}
+	switch {
+	case f.backoff == 0:
+		f.backoff = minBackoff
+	case f.backoff < maxBackoff:
+		f.backoff *= 2
+		if f.backoff > maxBackoff {
+			f.backoff = maxBackoff
+		}
+	}
+	if f.backoff > wait {
+		wait = f.backoff
+	}
+	return wait
+}
+
+// drop moves the payload to the failed directory of the queue.
+func (f *forwarder) drop(name string) {
+	delete(f.attempts, name)
+	if err := f.queue.fail(name); err != nil {
+		log.Printf("failed to move %s: %v", name, err)
+	}
+}
+
+// permanentError is the failure which is not retried.
+type permanentError struct {
+	error
+}
+
+// forward forwards the queued payload.
+func (f *forwarder) forward(ctx context.Context, name string) error {
+	b, err := f.queue.read(name)
+	if err != nil {
+		return err
+	}
+	var payload api.Payload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return permanentError{err}
+	}
+	if payload.Data == nil {
+		return permanentError{errors.New("missing data")}
+	}
+	if payload.AccessToken == "" {
+		if f.token == "" {
+			return permanentError{errors.New("missing access token")}
+		}
+		payload.AccessToken = f.token
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	if _, err := f.transport.Send(ctx, &payload); err != nil {
+		if errors.Is(err, rollbar.ErrPayloadTooLarge) {
+			return permanentError{err}
+		}
+		return err
+	}
+	return nil
+}
+
+// temporary reports whether the forwarding may succeed if retried later.
+func temporary(err error) bool {
+	var perr permanentError
+	if errors.As(err, &perr) {
+		return false
+	}
+	var apiErr *rollbar.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	return true // the network error
+}
@@ -0,0 +1,207 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// uuidTransport fails to send the payloads by their UUID.
+type uuidTransport struct {
+	mu   sync.Mutex
+	errs map[string]error
+	sent []string
+}
+
+func (t *uuidTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.errs[payload.Data.UUID]; err != nil {
+		return nil, err
+	}
+	t.sent = append(t.sent, payload.Data.UUID)
+	return &api.Response{}, nil
+}
+
+func Test_forwarder_forwardBatch(t *testing.T) {
+	rateLimited := &rollbar.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 10 * time.Minute}
+	unavailable := &rollbar.APIError{StatusCode: http.StatusServiceUnavailable}
+	tooLarge := &rollbar.APIError{StatusCode: http.StatusRequestEntityTooLarge}
+	unauthorized := &rollbar.APIError{StatusCode: http.StatusUnauthorized}
+
+	tests := []struct {
+		name        string
+		payloads    []string
+		errs        map[string]error
+		token       string
+		retries     int
+		maxAge      time.Duration
+		backoff     time.Duration
+		wantSent    []string
+		wantQueued  int
+		wantFailed  int
+		wantWait    time.Duration
+		wantBackoff time.Duration
+	}{
+		{
+			name:     "sent",
+			payloads: []string{`{"access_token":"x","data":{"uuid":"a"}}`, `{"data":{"uuid":"b"}}`},
+			token:    "default",
+			retries:  3,
+			backoff:  time.Minute,
+			wantSent: []string{"a", "b"},
+		},
+		{
+			name:        "temporary",
+			payloads:    []string{`{"access_token":"x","data":{"uuid":"a"}}`, `{"access_token":"x","data":{"uuid":"b"}}`},
+			errs:        map[string]error{"a": unavailable},
+			retries:     3,
+			wantSent:    []string{"b"},
+			wantQueued:  1,
+			wantWait:    minBackoff,
+			wantBackoff: minBackoff,
+		},
+		{
+			name:        "backoff",
+			payloads:    []string{`{"access_token":"x","data":{"uuid":"a"}}`},
+			errs:        map[string]error{"a": errors.New("connection refused")},
+			retries:     3,
+			backoff:     4 * time.Minute,
+			wantQueued:  1,
+			wantWait:    maxBackoff,
+			wantBackoff: maxBackoff,
+		},
+		{
+			name:        "rate limited",
+			payloads:    []string{`{"access_token":"x","data":{"uuid":"a"}}`},
+			errs:        map[string]error{"a": rateLimited},
+			retries:     3,
+			wantQueued:  1,
+			wantWait:    10 * time.Minute,
+			wantBackoff: minBackoff,
+		},
+		{
+			name:       "permanent",
+			payloads:   []string{`{"access_token":"x","data":{"uuid":"a"}}`, `{"data":{"uuid":"b"}}`, `{}`, `not json`},
+			errs:       map[string]error{"a": tooLarge},
+			retries:    3,
+			wantFailed: 4,
+		},
+		{
+			name:        "temporary not counted",
+			payloads:    []string{`{"access_token":"x","data":{"uuid":"a"}}`},
+			errs:        map[string]error{"a": unavailable},
+			retries:     1,
+			wantQueued:  1,
+			wantWait:    minBackoff,
+			wantBackoff: minBackoff,
+		},
+		{
+			name:       "too old",
+			payloads:   []string{`{"access_token":"x","data":{"uuid":"a"}}`},
+			errs:       map[string]error{"a": unavailable},
+			retries:    3,
+			maxAge:     time.Nanosecond,
+			wantFailed: 1,
+		},
+		{
+			name:        "rejected",
+			payloads:    []string{`{"access_token":"x","data":{"uuid":"a"}}`},
+			errs:        map[string]error{"a": unauthorized},
+			retries:     3,
+			wantQueued:  1,
+			wantWait:    minBackoff,
+			wantBackoff: minBackoff,
+		},
+		{
+			name:       "retries exhausted",
+			payloads:   []string{`{"access_token":"x","data":{"uuid":"a"}}`},
+			errs:       map[string]error{"a": unauthorized},
+			retries:    1,
+			wantFailed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			q, err := openQueue(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range tt.payloads {
+				if err := q.push([]byte(p + "\n")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			names, err := q.list(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tr := &uuidTransport{errs: tt.errs}
+			f := &forwarder{
+				queue:     q,
+				transport: tr,
+				token:     tt.token,
+				retries:   tt.retries,
+				maxAge:    tt.maxAge,
+				timeout:   time.Second,
+				attempts:  make(map[string]int),
+				backoff:   tt.backoff,
+			}
+			wait := f.forwardBatch(context.Background(), names)
+
+			sort.Strings(tr.sent)
+			if !reflect.DeepEqual(tr.sent, tt.wantSent) {
+				t.Errorf("sent = %v, want %v", tr.sent, tt.wantSent)
+			}
+			if wait != tt.wantWait {
+				t.Errorf("forwardBatch() = %v, want %v", wait, tt.wantWait)
+			}
+			if f.backoff != tt.wantBackoff {
+				t.Errorf("backoff = %v, want %v", f.backoff, tt.wantBackoff)
+			}
+			if got := q.len(); got != tt.wantQueued {
+				t.Errorf("queued = %d, want %d", got, tt.wantQueued)
+			}
+			failed, err := filepath.Glob(filepath.Join(dir, failedDir, "*"+queueExt))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(failed) != tt.wantFailed {
+				t.Errorf("failed = %d, want %d", len(failed), tt.wantFailed)
+			}
+		})
+	}
+}
+
+func Test_temporary(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: errors.New("connection refused"), want: true},
+		{err: &rollbar.APIError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{err: &rollbar.APIError{StatusCode: http.StatusBadGateway}, want: true},
+		{err: &rollbar.APIError{StatusCode: http.StatusUnauthorized}, want: false},
+		{err: permanentError{errors.New("missing data")}, want: false},
+	}
+	for _, tt := range tests {
+		if got := temporary(tt.err); got != tt.want {
+			t.Errorf("temporary(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command rollbar-relay accepts the rollbar payloads from the local processes,
+// and forwards them to rollbar.
+//
+// The short-lived processes, such as the cron jobs and the CLI tools, hand the
+// payloads over to the relay in microseconds with rollbar.WithRelay, instead of
+// waiting for the HTTPS round-trips to rollbar. The relay listens on a Unix
+// domain socket for the lines of the payload JSON, on UDP for a payload per
+// datagram, or on a localhost HTTP port for the payloads posted to /api/1/item/.
+//
+// The payloads are queued to disk, so they survive the restart of the relay,
+// and forwarded in batches within the rate. The temporary failures, such as the
+// network errors and the 5xx responses, are retried with the backoff until the
+// payload gets older than -max-age, and the rate limited payloads after the
+// time rollbar asks. The payloads rejected by rollbar are retried up to
+// -retries attempts. The payloads failed permanently are moved to the failed
+// directory of the queue, in the format of cmd/rollbar-replay.
+//
+// Usage:
+//
+//	rollbar-relay [flags]
+//
+// For example:
+//
+//	rollbar-relay -unix /run/rollbar.sock -queue /var/spool/rollbar
+//
+// and in the application:
+//
+//	rollbar.New(token, rollbar.WithRelay("unix", "/run/rollbar.sock"))
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+var (
+	flagUnix     = flag.String("unix", "", "path of the Unix domain socket to listen on")
+	flagUnixMode = flag.String("unix-mode", "0660", "permission bits of the Unix domain socket, in octal")
+	flagUDP      = flag.String("udp", "", "UDP address to listen on, such as localhost:8643")
+	flagHTTP     = flag.String("http", "", "HTTP address to listen on, such as localhost:8643")
+	flagQueue    = flag.String("queue", filepath.Join(os.TempDir(), "rollbar-relay"), "directory of the disk queue")
+	flagMaxQueue = flag.Int("max-queue", 10000, "upper limit of the queued payloads, the newer are dropped")
+	flagToken    = flag.String("token", os.Getenv("ROLLBAR_TOKEN"), "access token of the payloads without one (default $ROLLBAR_TOKEN)")
+	flagEndpoint = flag.String("endpoint", api.DefaultEndpoint, "rollbar API endpoint")
+	flagBatch    = flag.Int("batch", 10, "number of the payloads forwarded at once")
+	flagRate     = flag.Int("rate", 0, "upper limit of the payloads forwarded per minute (default unlimited)")
+	flagRetries  = flag.Int("retries", 3, "maximum attempts to forward a payload rejected by rollbar")
+	flagMaxAge   = flag.Duration("max-age", 72*time.Hour, "maximum age of a payload retried for the temporary failures (0 for unlimited)")
+	flagTimeout  = flag.Duration("timeout", 10*time.Second, "timeout of forwarding a payload")
+	flagGzip     = flag.Int("gzip", 0, "size threshold in bytes to compress the forwarded payload (default disabled)")
+)
+
+const (
+	// maxLineSize is the upper limit of the payload line on the Unix domain socket.
+	maxLineSize = 16 << 20
+	// maxDatagramSize is the upper limit of the UDP datagram.
+	maxDatagramSize = 64 << 10
+	// maxBodySize is the upper limit of the posted payload.
+	maxBodySize = 16 << 20
+	// acceptBackoff is the wait after the failure to accept a connection or read
+	// a datagram, such as by the limit of the open files.
+	acceptBackoff = 100 * time.Millisecond
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetPrefix("rollbar-relay: ")
+	if *flagUnix == "" && *flagUDP == "" && *flagHTTP == "" {
+		fmt.Fprintln(os.Stderr, "rollbar-relay: one of -unix, -udp or -http is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	unixMode, err := strconv.ParseUint(*flagUnixMode, 8, 32)
+	if err != nil || unixMode > 0777 {
+		fmt.Fprintf(os.Stderr, "rollbar-relay: invalid -unix-mode %q\n", *flagUnixMode)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	q, err := openQueue(*flagQueue, *flagMaxQueue)
+	if err != nil {
+		log.Fatalf("failed to open queue: %v", err)
+	}
+	r := &relay{queue: q}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var closers []io.Closer
+	if *flagUnix != "" {
+		ln, err := listenUnix(*flagUnix, os.FileMode(unixMode))
+		if err != nil {
+			log.Fatal(err)
+		}
+		closers = append(closers, ln)
+		go r.serveUnix(ln)
+		log.Printf("listening on unix %s", *flagUnix)
+	}
+	if *flagUDP != "" {
+		pc, err := net.ListenPacket("udp", *flagUDP)
+		if err != nil {
+			log.Fatal(err)
+		}
+		closers = append(closers, pc)
+		go r.serveUDP(pc)
+		log.Printf("listening on udp %s", pc.LocalAddr())
+	}
+	if *flagHTTP != "" {
+		ln, err := net.Listen("tcp", *flagHTTP)
+		if err != nil {
+			log.Fatal(err)
+		}
+		closers = append(closers, ln)
+		mux := http.NewServeMux()
+		mux.Handle("/api/1/item/", r)
+		go http.Serve(ln, mux)
+		log.Printf("listening on http://%s/api/1/item/", ln.Addr())
+	}
+
+	opts := []rollbar.Option{rollbar.WithEndpoint(*flagEndpoint)}
+	if *flagGzip > 0 {
+		opts = append(opts, rollbar.WithGzip(*flagGzip))
+	}
+	f := &forwarder{
+		queue:     q,
+		transport: rollbar.NewHTTPTransport(opts...),
+		token:     *flagToken,
+		batch:     *flagBatch,
+		retries:   *flagRetries,
+		maxAge:    *flagMaxAge,
+		timeout:   *flagTimeout,
+	}
+	if *flagRate > 0 {
+		f.interval = time.Minute / time.Duration(*flagRate)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f.run(ctx)
+	}()
+	log.Printf("forwarding to %s, %d payloads queued in %s", *flagEndpoint, q.len(), *flagQueue)
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	<-sigc
+	log.Printf("shutting down, %d payloads queued", q.len())
+	for _, c := range closers {
+		c.Close()
+	}
+	cancel()
+	wg.Wait()
+}
+
+// listenUnix listens on the Unix domain socket at path, removing the stale socket.
+// The socket is restricted to mode, as any process which can write to it may
+// report to rollbar with the token of the relay.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use", path)
+		}
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path) // the listener removes the socket when closed
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// relay receives the payloads and queues them.
+type relay struct {
+	queue *queue
+}
+
+// accept validates and queues the payload JSON. It returns the payload UUID.
+func (r *relay) accept(b []byte) (string, error) {
+	var payload api.Payload
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return "", fmt.Errorf("invalid JSON: %v", err)
+	}
+	if payload.Data == nil {
+		return "", fmt.Errorf("missing data")
+	}
+	line, err := payload.AppendJSON(nil)
+	if err != nil {
+		return "", err
+	}
+	if err := r.queue.push(append(line, '\n')); err != nil {
+		return "", err
+	}
+	return payload.Data.UUID, nil
+}
+
+func (r *relay) serveUnix(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("failed to accept: %v", err)
+			time.Sleep(acceptBackoff)
+			continue
+		}
+		go r.serveConn(conn)
+	}
+}
+
+// serveConn queues the payload lines of the connection.
+func (r *relay) serveConn(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	for sc.Scan() {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		if _, err := r.accept(sc.Bytes()); err != nil {
+			log.Printf("dropped payload: %v", err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		log.Printf("failed to read connection: %v", err)
+	}
+}
+
+// serveUDP queues the payload datagrams.
+func (r *relay) serveUDP(pc net.PacketConn) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("failed to read datagram: %v", err)
+			time.Sleep(acceptBackoff)
+			continue
+		}
+		if _, err := r.accept(buf[:n]); err != nil {
+			log.Printf("dropped payload: %v", err)
+		}
+	}
+}
+
+// ServeHTTP queues the posted payload, and responds as the rollbar API does.
+func (r *relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, &api.Response{Err: 1, Message: "method not allowed"})
+		return
+	}
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, &api.Response{Err: 1, Message: "invalid gzip body"})
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(body, maxBodySize+1))
+	switch {
+	case err != nil:
+		writeResponse(w, http.StatusBadRequest, &api.Response{Err: 1, Message: "failed to read body"})
+		return
+	case len(b) > maxBodySize:
+		writeResponse(w, http.StatusRequestEntityTooLarge, &api.Response{Err: 1, Message: "payload too large"})
+		return
+	}
+
+	uuid, err := r.accept(b)
+	switch {
+	case err == errQueueFull:
+		w.Header().Set("Retry-After", "60")
+		writeResponse(w, http.StatusServiceUnavailable, &api.Response{Err: 1, Message: err.Error()})
+	case err != nil:
+		writeResponse(w, http.StatusBadRequest, &api.Response{Err: 1, Message: err.Error()})
+	default:
+		writeResponse(w, http.StatusOK, &api.Response{Result: api.Result{UUID: uuid}})
+	}
+}
+
+func writeResponse(w http.ResponseWriter, code int, res *api.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func Test_relay_serveUDP_closed(t *testing.T) {
+	q, err := openQueue(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &relay{queue: q}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp is not supported: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		r.serveUDP(pc)
+		close(done)
+	}()
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(`{"data":{"uuid":"a"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); q.len() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("payload is not queued")
+		}
+	}
+
+	pc.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveUDP does not return after the close")
+	}
+}
+
+func Test_listenUnix_mode(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skipf("the socket permission is not supported on %s", runtime.GOOS)
+	}
+	path := filepath.Join(t.TempDir(), "rollbar.sock")
+	ln, err := listenUnix(path, 0660)
+	if err != nil {
+		t.Skipf("unix is not supported: %v", err)
+	}
+	defer ln.Close()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fi.Mode().Perm(); got != 0660 {
+		t.Errorf("mode = %v, want %v", got, os.FileMode(0660))
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errQueueFull is returned when the queue has the maximum number of the payloads.
+var errQueueFull = errors.New("queue is full")
+
+// queue is the queue of the payloads on disk, a file per payload. The files
+// are written atomically, and named in the order of the arrival, so the queue
+// survives the restart of the relay.
+type queue struct {
+	dir string
+	max int
+
+	mu    sync.Mutex
+	seq   uint64
+	count int
+	ready chan struct{} // signaled when a payload is added
+}
+
+// openQueue opens the queue in dir, creating it if not exists.
+func openQueue(dir string, max int) (*queue, error) {
+	if err := os.MkdirAll(filepath.Join(dir, failedDir), 0700); err != nil {
+		return nil, err
+	}
+	q := &queue{dir: dir, max: max, ready: make(chan struct{}, 1)}
+	names, err := q.list(0)
+	if err != nil {
+		return nil, err
+	}
+	q.count = len(names)
+	return q, nil
+}
+
+const (
+	// queueExt is the extension of the queued payload files.
+	queueExt = ".json"
+	// failedDir is the directory of the payloads failed to forward, in the queue.
+	failedDir = "failed"
+)
+
+// push adds the encoded payload to the queue.
+func (q *queue) push(b []byte) error {
+	q.mu.Lock()
+	if q.max > 0 && q.count >= q.max {
+		q.mu.Unlock()
+		return errQueueFull
+	}
+	q.seq++
+	name := fmt.Sprintf("%019d-%06d%s", time.Now().UnixNano(), q.seq%1000000, queueExt)
+	q.count++
+	q.mu.Unlock()
+
+	tmp := filepath.Join(q.dir, "."+name+".tmp")
+	err := ioutil.WriteFile(tmp, b, 0600)
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(q.dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		q.mu.Lock()
+		q.count--
+		q.mu.Unlock()
+		return err
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// list returns at most n names of the queued payloads, the oldest first.
+// A n of zero or less returns all.
+func (q *queue) list(n int) ([]string, error) {
+	f, err := os.Open(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	all, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	names := all[:0]
+	for _, name := range all {
+		if strings.HasSuffix(name, queueExt) && !strings.HasPrefix(name, ".") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if n > 0 && len(names) > n {
+		names = names[:n]
+	}
+	return names, nil
+}
+
+// read reads the queued payload.
+func (q *queue) read(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(q.dir, name))
+}
+
+// remove removes the payload from the queue.
+func (q *queue) remove(name string) error {
+	err := os.Remove(filepath.Join(q.dir, name))
+	if err == nil {
+		q.mu.Lock()
+		q.count--
+		q.mu.Unlock()
+	}
+	return err
+}
+
+// fail moves the payload to the failed directory, to be inspected or replayed
+// by cmd/rollbar-replay later.
+func (q *queue) fail(name string) error {
+	err := os.Rename(filepath.Join(q.dir, name), filepath.Join(q.dir, failedDir, name))
+	if err == nil {
+		q.mu.Lock()
+		q.count--
+		q.mu.Unlock()
+	}
+	return err
+}
+
+// queuedAt returns the time the payload was pushed, from the name of its file.
+// It returns the zero time for the unknown name.
+func queuedAt(name string) time.Time {
+	i := strings.IndexByte(name, '-')
+	if i < 0 {
+		return time.Time{}
+	}
+	ns, err := strconv.ParseInt(name[:i], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// len returns the number of the queued payloads.
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.count
+}
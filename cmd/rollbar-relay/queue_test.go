@@ -0,0 +1,90 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_queue(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openQueue(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range []string{"a\n", "b\n", "c\n"} {
+		if err := q.push([]byte(b)); err != nil {
+			t.Fatalf("push(%q) error = %v", b, err)
+		}
+	}
+	if err := q.push([]byte("d\n")); err != errQueueFull {
+		t.Errorf("push() error = %v, want %v", err, errQueueFull)
+	}
+	if got := q.len(); got != 3 {
+		t.Errorf("len() = %d, want 3", got)
+	}
+
+	names, err := q.list(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, name := range names {
+		b, err := q.read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(b))
+	}
+	if want := []string{"a\n", "b\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("list(2) = %q, want the oldest %q", got, want)
+	}
+
+	if err := q.remove(names[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.fail(names[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := q.len(); got != 1 {
+		t.Errorf("len() = %d, want 1", got)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, failedDir, names[1])); err != nil || string(b) != "b\n" {
+		t.Errorf("failed payload = %q, %v, want %q", b, err, "b\n")
+	}
+
+	// the queue survives the restart
+	q, err = openQueue(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.len(); got != 1 {
+		t.Errorf("len() after reopen = %d, want 1", got)
+	}
+	names, err = q.list(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("list(0) = %v, want 1 name", names)
+	}
+	if b, err := q.read(names[0]); err != nil || string(b) != "c\n" {
+		t.Errorf("read() = %q, %v, want %q", b, err, "c\n")
+	}
+}
+
+func Test_queuedAt(t *testing.T) {
+	if got, want := queuedAt("0001508457600000000000-000001.json"), time.Unix(1508457600, 0); !got.Equal(want) {
+		t.Errorf("queuedAt() = %v, want %v", got, want)
+	}
+	if got := queuedAt("payload.json"); !got.IsZero() {
+		t.Errorf("queuedAt() = %v, want the zero time", got)
+	}
+}
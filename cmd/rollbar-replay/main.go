@@ -0,0 +1,261 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command rollbar-replay re-sends the payloads recorded by rollbar.Recorder.
+//
+// The files are read in order, or the standard input if none is given. Pass
+// the rotated files from the oldest, such as items.jsonl.2 items.jsonl.1 items.jsonl.
+// The payloads are sent to the endpoint with the token, optionally rewriting
+// the environment and the timestamp, and filtered by the level and the time range.
+// The rate limited payloads are retried after the time the API asks.
+//
+// The payloads keep their recorded data.uuid, and rollbar discards the
+// occurrence of the UUID it has already received without an error. To replay
+// the payloads into the project which received them, such as to test the
+// notifications, pass -new-uuid to send them with the new UUIDs.
+//
+// Usage:
+//
+//	rollbar-replay [flags] [file...]
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+var (
+	flagToken       = flag.String("token", os.Getenv("ROLLBAR_TOKEN"), "rollbar access token with post_server_item scope (default $ROLLBAR_TOKEN)")
+	flagEndpoint    = flag.String("endpoint", api.DefaultEndpoint, "rollbar API endpoint")
+	flagEnvironment = flag.String("environment", "", "rewrite the environment of the items")
+	flagNow         = flag.Bool("now", false, "rewrite the timestamp of the items to the time sent")
+	flagNewUUID     = flag.Bool("new-uuid", false, "rewrite the UUID of the items, not to be discarded as the duplicates by rollbar")
+	flagLevels      = flag.String("level", "", "comma separated levels of the items to send, such as \"error,critical\" (default all)")
+	flagSince       = flag.String("since", "", "send the items at or after the time, in RFC 3339")
+	flagUntil       = flag.String("until", "", "send the items before the time, in RFC 3339")
+	flagRate        = flag.Int("rate", 0, "upper limit of the items sent per minute (default unlimited)")
+	flagDryRun      = flag.Bool("dry-run", false, "print the items instead of sending them")
+	flagTimeout     = flag.Duration("timeout", 10*time.Second, "timeout of sending an item")
+)
+
+// maxLineSize is the upper limit of the recorded payload line.
+const maxLineSize = 16 << 20
+
+// defaultRetryAfter is the wait of the rate limited item, if the API does not tell.
+const defaultRetryAfter = time.Minute
+
+type filter struct {
+	levels map[string]bool
+	since  time.Time
+	until  time.Time
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] [file...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	f, err := newFilter(*flagLevels, *flagSince, *flagUntil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rollbar-replay: %v\n", err)
+		os.Exit(2)
+	}
+	if *flagToken == "" && !*flagDryRun {
+		fmt.Fprintln(os.Stderr, "rollbar-replay: empty token")
+		os.Exit(2)
+	}
+
+	var tr rollbar.Transport
+	if *flagDryRun {
+		tr = rollbar.NewConsoleTransport(os.Stdout, false)
+	} else {
+		tr = rollbar.NewHTTPTransport(rollbar.WithEndpoint(*flagEndpoint))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	go func() {
+		<-sigc
+		cancel()
+	}()
+
+	r := &replayer{transport: tr, filter: f}
+	if *flagRate > 0 {
+		r.interval = time.Minute / time.Duration(*flagRate)
+	}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		if err := r.replayFile(ctx, name); err != nil {
+			fmt.Fprintf(os.Stderr, "rollbar-replay: %v\n", err)
+			break
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "rollbar-replay: %d sent, %d skipped, %d failed\n", r.sent, r.skipped, r.failed)
+	if r.failed > 0 || ctx.Err() != nil {
+		os.Exit(1)
+	}
+}
+
+func newFilter(levels, since, until string) (*filter, error) {
+	f := new(filter)
+	if levels != "" {
+		f.levels = make(map[string]bool)
+		for _, l := range strings.Split(levels, ",") {
+			f.levels[strings.TrimSpace(l)] = true
+		}
+	}
+	var err error
+	if since != "" {
+		if f.since, err = time.Parse(time.RFC3339, since); err != nil {
+			return nil, fmt.Errorf("invalid -since: %v", err)
+		}
+	}
+	if until != "" {
+		if f.until, err = time.Parse(time.RFC3339, until); err != nil {
+			return nil, fmt.Errorf("invalid -until: %v", err)
+		}
+	}
+	return f, nil
+}
+
+// match reports whether the item data d passes the filter.
+func (f *filter) match(d *api.Data) bool {
+	if f.levels != nil && !f.levels[d.Level] {
+		return false
+	}
+	t := time.Unix(d.Timestamp, 0)
+	if !f.since.IsZero() && t.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !t.Before(f.until) {
+		return false
+	}
+	return true
+}
+
+type replayer struct {
+	transport rollbar.Transport
+	filter    *filter
+	interval  time.Duration
+	last      time.Time
+
+	sent, skipped, failed int
+}
+
+func (r *replayer) replayFile(ctx context.Context, name string) error {
+	var rd io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rd = f
+	}
+
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var payload api.Payload
+		if err := json.Unmarshal(sc.Bytes(), &payload); err != nil {
+			fmt.Fprintf(os.Stderr, "rollbar-replay: %s:%d: %v\n", name, line, err)
+			r.failed++
+			continue
+		}
+		if payload.Data == nil || !r.filter.match(payload.Data) {
+			r.skipped++
+			continue
+		}
+
+		payload.AccessToken = *flagToken
+		if *flagEnvironment != "" {
+			payload.Data.Environment = *flagEnvironment
+		}
+		if *flagNow {
+			payload.Data.Timestamp = time.Now().Unix()
+		}
+		if *flagNewUUID {
+			payload.Data.UUID = rollbar.NewUUID()
+		}
+
+		if err := r.send(ctx, &payload); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "rollbar-replay: %s:%d: %v\n", name, line, err)
+			r.failed++
+			continue
+		}
+		r.sent++
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// send sends the payload within the rate, and retries it while it is rate limited.
+func (r *replayer) send(ctx context.Context, payload *api.Payload) error {
+	for {
+		if err := sleep(ctx, r.interval-time.Since(r.last)); err != nil {
+			return err
+		}
+		r.last = time.Now()
+
+		sctx, cancel := context.WithTimeout(ctx, *flagTimeout)
+		_, err := r.transport.Send(sctx, payload)
+		cancel()
+
+		var apiErr *rollbar.APIError
+		if !errors.As(err, &apiErr) || !errors.Is(apiErr, rollbar.ErrRateLimited) {
+			return err
+		}
+		wait := apiErr.RetryAfter
+		if wait <= 0 {
+			wait = defaultRetryAfter
+		}
+		fmt.Fprintf(os.Stderr, "rollbar-replay: rate limited, retrying after %v\n", wait)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
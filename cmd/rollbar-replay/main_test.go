@@ -0,0 +1,212 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func Test_filter_match(t *testing.T) {
+	at := func(s string) int64 {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm.Unix()
+	}
+
+	tests := []struct {
+		name   string
+		levels string
+		since  string
+		until  string
+		data   *api.Data
+		want   bool
+	}{
+		{
+			name: "no filter",
+			data: &api.Data{Level: "debug", Timestamp: at("2017-10-01T00:00:00Z")},
+			want: true,
+		},
+		{
+			name:   "level",
+			levels: "error, critical",
+			data:   &api.Data{Level: "critical"},
+			want:   true,
+		},
+		{
+			name:   "other level",
+			levels: "error,critical",
+			data:   &api.Data{Level: "warning"},
+			want:   false,
+		},
+		{
+			name:  "at since",
+			since: "2017-10-01T00:00:00Z",
+			data:  &api.Data{Timestamp: at("2017-10-01T00:00:00Z")},
+			want:  true,
+		},
+		{
+			name:  "before since",
+			since: "2017-10-01T00:00:00Z",
+			data:  &api.Data{Timestamp: at("2017-09-30T23:59:59Z")},
+			want:  false,
+		},
+		{
+			name:  "before until",
+			until: "2017-10-01T00:00:00Z",
+			data:  &api.Data{Timestamp: at("2017-09-30T23:59:59Z")},
+			want:  true,
+		},
+		{
+			name:  "at until",
+			until: "2017-10-01T00:00:00Z",
+			data:  &api.Data{Timestamp: at("2017-10-01T00:00:00Z")},
+			want:  false,
+		},
+		{
+			name:   "all",
+			levels: "error",
+			since:  "2017-10-01T00:00:00Z",
+			until:  "2017-10-02T00:00:00+09:00",
+			data:   &api.Data{Level: "error", Timestamp: at("2017-10-01T12:00:00Z")},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFilter(tt.levels, tt.since, tt.until)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.match(tt.data); got != tt.want {
+				t.Errorf("filter.match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newFilter_invalid(t *testing.T) {
+	if _, err := newFilter("", "yesterday", ""); err == nil {
+		t.Error("newFilter() error = nil, want invalid -since")
+	}
+	if _, err := newFilter("", "", "2017-10-01"); err == nil {
+		t.Error("newFilter() error = nil, want invalid -until")
+	}
+}
+
+// scriptTransport returns the scripted errors in order, and nil after them.
+type scriptTransport struct {
+	errs  []error
+	calls int
+}
+
+func (t *scriptTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	t.calls++
+	if len(t.errs) == 0 {
+		return &api.Response{}, nil
+	}
+	err := t.errs[0]
+	t.errs = t.errs[1:]
+	return nil, err
+}
+
+func Test_replayer_send(t *testing.T) {
+	rateLimited := &rollbar.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 10 * time.Millisecond}
+	unavailable := &rollbar.APIError{StatusCode: http.StatusServiceUnavailable}
+	errNetwork := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "sent",
+			wantCalls: 1,
+		},
+		{
+			name:      "rate limited",
+			errs:      []error{rateLimited, rateLimited},
+			wantCalls: 3,
+		},
+		{
+			name:      "unavailable",
+			errs:      []error{unavailable},
+			wantErr:   unavailable,
+			wantCalls: 1,
+		},
+		{
+			name:      "network error",
+			errs:      []error{errNetwork},
+			wantErr:   errNetwork,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &scriptTransport{errs: tt.errs}
+			r := &replayer{transport: tr}
+			err := r.send(context.Background(), &api.Payload{Data: &api.Data{}})
+			if err != tt.wantErr {
+				t.Errorf("replayer.send() error = %v, want %v", err, tt.wantErr)
+			}
+			if tr.calls != tt.wantCalls {
+				t.Errorf("Send calls = %d, want %d", tr.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_replayer_send_canceled(t *testing.T) {
+	tr := &scriptTransport{errs: []error{&rollbar.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}}}
+	r := &replayer{transport: tr}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.send(ctx, &api.Payload{Data: &api.Data{}}); err != context.DeadlineExceeded {
+		t.Errorf("replayer.send() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func Test_replayer_replayFile_newUUID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.jsonl")
+	if err := ioutil.WriteFile(path, []byte(`{"data":{"environment":"production","uuid":"6ba7b810-9dad-41d1-80b4-00c04fd430c8"}}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(v bool) { *flagNewUUID = v }(*flagNewUUID)
+
+	tests := []struct {
+		newUUID  bool
+		wantSame bool
+	}{
+		{newUUID: false, wantSame: true},
+		{newUUID: true, wantSame: false},
+	}
+	for _, tt := range tests {
+		*flagNewUUID = tt.newUUID
+		ch := make(chan *api.Payload, 1)
+		r := &replayer{transport: rollbar.NewChanTransport(ch), filter: new(filter)}
+		if err := r.replayFile(context.Background(), path); err != nil {
+			t.Fatal(err)
+		}
+		if r.sent != 1 {
+			t.Fatalf("sent = %d, want 1", r.sent)
+		}
+		uuid := (<-ch).Data.UUID
+		if same := uuid == "6ba7b810-9dad-41d1-80b4-00c04fd430c8"; same != tt.wantSame || len(uuid) != 36 {
+			t.Errorf("-new-uuid=%v: UUID = %q, want the recorded UUID %v", tt.newUUID, uuid, tt.wantSame)
+		}
+	}
+}
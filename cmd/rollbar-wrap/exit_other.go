@@ -0,0 +1,27 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build plan9 || js || wasip1
+// +build plan9 js wasip1
+
+package main
+
+import (
+	"os"
+)
+
+// forwardedSignals are the signals forwarded to the child.
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// exitStatus returns the exit code of the child. The terminating signal is not
+// known on this platform.
+func exitStatus(ps *os.ProcessState, err error) (int, os.Signal) {
+	if ps == nil {
+		if err != nil {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return ps.ExitCode(), nil
+}
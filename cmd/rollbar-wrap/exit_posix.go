@@ -0,0 +1,31 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !plan9 && !js && !wasip1
+// +build !plan9,!js,!wasip1
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// forwardedSignals are the signals forwarded to the child.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// exitStatus returns the exit code and the terminating signal of the child.
+// A child killed by a signal exits with 128+signal, as the shell does.
+func exitStatus(ps *os.ProcessState, err error) (int, os.Signal) {
+	if ps == nil {
+		if err != nil {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	if ws, ok := ps.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal()), ws.Signal()
+	}
+	return ps.ExitCode(), nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command rollbar-wrap runs a child process and reports its crash to rollbar.
+//
+// The child stderr is passed through to the rollbar-wrap stderr. When the child
+// exits abnormally, rollbar-wrap parses the Go panic or fatal error dump from
+// its stderr and sends it as a critical item, with the exit code, signal and the
+// last lines of the output. Then rollbar-wrap exits with the child status.
+//
+// The child killed by a signal is reported even without a dump, unless the
+// signal was sent to rollbar-wrap and forwarded to the child, such as on the
+// stop of the service.
+//
+// Usage:
+//
+//	rollbar-wrap [flags] -- command [args...]
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	rollbar "github.com/zchee/go-rollbar"
+	"golang.org/x/net/context"
+)
+
+var (
+	flagToken       = flag.String("token", os.Getenv("ROLLBAR_TOKEN"), "rollbar access token with post_server_item scope (default $ROLLBAR_TOKEN)")
+	flagEnvironment = flag.String("environment", envOr("ROLLBAR_ENVIRONMENT", "production"), "name of the environment (default $ROLLBAR_ENVIRONMENT)")
+	flagCodeVersion = flag.String("code-version", os.Getenv("ROLLBAR_CODE_VERSION"), "version of the child application code (default $ROLLBAR_CODE_VERSION)")
+	flagEndpoint    = flag.String("endpoint", "", "alternate rollbar API endpoint")
+	flagLines       = flag.Int("lines", 100, "number of last output lines to send")
+	flagAllExits    = flag.Bool("all-exits", false, "report any non-zero exit, even without a crash dump")
+	flagTimeout     = flag.Duration("timeout", 10*time.Second, "timeout of sending the crash report")
+)
+
+// maxDumpSize is the upper limit of the captured crash dump.
+const maxDumpSize = 4 << 20
+
+// maxLineSize is the upper limit of the output line. The longer output without
+// the newline, such as the progress bar, is split into the lines of the size.
+const maxLineSize = 64 << 10
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] -- command [args...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	os.Exit(run(flag.Args()))
+}
+
+func run(args []string) int {
+	out := newCrashWriter(os.Stderr, *flagLines)
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = out
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "rollbar-wrap: %v\n", err)
+		return 127
+	}
+
+	// forward the signals to the child, and wait for its exit. The forwarded
+	// signals are recorded, not to report the child stopped by them as crashed.
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, forwardedSignals...)
+	forwarded := make(map[os.Signal]bool)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for sig := range sigc {
+			forwarded[sig] = true
+			cmd.Process.Signal(sig)
+		}
+	}()
+	err := cmd.Wait()
+	signal.Stop(sigc)
+	close(sigc)
+	<-done
+
+	code, sig := exitStatus(cmd.ProcessState, err)
+	if code == 0 {
+		return 0
+	}
+
+	d, perr := rollbar.ParseDump(bytes.NewReader(out.Dump()))
+	switch {
+	case perr == nil:
+	case *flagAllExits, sig != nil && !forwarded[sig]:
+		d = &rollbar.Dump{
+			Message: cmd.ProcessState.String(),
+			Raw:     out.Tail(),
+		}
+	default:
+		return code
+	}
+
+	if err := report(d, args, code, sig, out.Tail()); err != nil {
+		fmt.Fprintf(os.Stderr, "rollbar-wrap: failed to report the crash: %v\n", err)
+	}
+
+	return code
+}
+
+// report sends the crash dump to rollbar as a critical item.
+func report(d *rollbar.Dump, args []string, code int, sig os.Signal, tail string) error {
+	if *flagToken == "" {
+		return fmt.Errorf("empty token")
+	}
+
+	opts := []rollbar.Option{
+		rollbar.WithEnvironment(*flagEnvironment),
+		rollbar.WithBuildInfo(false), // the build of rollbar-wrap, not of the command
+	}
+	if *flagCodeVersion != "" {
+		opts = append(opts, rollbar.WithCodeVersion(*flagCodeVersion))
+	}
+	if *flagEndpoint != "" {
+		opts = append(opts, rollbar.WithEndpoint(*flagEndpoint))
+	}
+	client := rollbar.New(*flagToken, opts...)
+
+	custom := map[string]interface{}{
+		"command":   strings.Join(args, " "),
+		"exit_code": code,
+		"output":    tail,
+	}
+	if sig != nil {
+		custom["signal"] = sig.String()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *flagTimeout)
+	defer cancel()
+	_, err := client.Critical(d).Custom(custom).Do(ctx)
+	return err
+}
+
+// crashWriter writes through to w, and keeps the last lines and the last crash dump.
+type crashWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	partial []byte
+	lines   []string
+	next    int
+	full    bool
+	dump    bytes.Buffer
+	inDump  bool
+	sigLine string // the signal header line, until the next line confirms the dump
+}
+
+func newCrashWriter(w io.Writer, n int) *crashWriter {
+	if n < 1 {
+		n = 1
+	}
+	return &crashWriter{
+		w:     w,
+		lines: make([]string, n),
+	}
+}
+
+// Write implements io.Writer.
+func (c *crashWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partial = append(c.partial, p...)
+	for {
+		i := bytes.IndexByte(c.partial, '\n')
+		if i < 0 {
+			break
+		}
+		c.line(string(c.partial[:i]))
+		c.partial = c.partial[i+1:]
+	}
+	for len(c.partial) >= maxLineSize {
+		c.line(string(c.partial[:maxLineSize]))
+		c.partial = c.partial[maxLineSize:]
+	}
+	if cap(c.partial) > 2*maxLineSize {
+		c.partial = append([]byte(nil), c.partial...) // not to hold the consumed output
+	}
+
+	return n, err
+}
+
+func (c *crashWriter) line(line string) {
+	c.lines[c.next] = line
+	c.next = (c.next + 1) % len(c.lines)
+	if c.next == 0 {
+		c.full = true
+	}
+
+	// the runtime starts a crash dump at the beginning of the line, so the
+	// last one wins over any panic text logged by the application before.
+	// The signal header, such as "SIGSEGV: segmentation violation", starts the
+	// dump only if the runtime prints the "PC=" line after it.
+	sigLine := c.sigLine
+	c.sigLine = ""
+	switch {
+	case isDumpHeader(line):
+		c.startDump()
+	case sigLine != "" && strings.HasPrefix(line, "PC="):
+		c.startDump()
+		c.writeDump(sigLine)
+	case signalHeader.MatchString(line):
+		c.sigLine = line
+	}
+	c.writeDump(line)
+}
+
+func (c *crashWriter) startDump() {
+	c.dump.Reset()
+	c.inDump = true
+}
+
+func (c *crashWriter) writeDump(line string) {
+	if c.inDump && c.dump.Len() < maxDumpSize {
+		c.dump.WriteString(line)
+		c.dump.WriteByte('\n')
+	}
+}
+
+// signalHeader matches the first line of the crash dump of the signal, such as
+// "SIGSEGV: segmentation violation".
+var signalHeader = regexp.MustCompile(`^SIG[A-Z0-9]+: `)
+
+func isDumpHeader(line string) bool {
+	return strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ")
+}
+
+// Dump returns the last crash dump, including any unterminated line.
+func (c *crashWriter) Dump() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := append([]byte(nil), c.dump.Bytes()...)
+	if c.inDump {
+		b = append(b, c.partial...)
+	}
+	return b
+}
+
+// Tail returns the last lines of the output.
+func (c *crashWriter) Tail() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lines []string
+	if c.full {
+		lines = append(lines, c.lines[c.next:]...)
+	}
+	lines = append(lines, c.lines[:c.next]...)
+	if len(c.partial) > 0 {
+		if len(lines) == len(c.lines) {
+			lines = lines[1:] // the unterminated line is one of the last lines
+		}
+		lines = append(lines, string(c.partial))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
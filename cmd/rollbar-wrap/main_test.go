@@ -0,0 +1,126 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_crashWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    int
+		writes   []string
+		wantDump string
+		wantTail string
+	}{
+		{
+			name:     "no crash",
+			lines:    3,
+			writes:   []string{"starting\n", "serving\n"},
+			wantDump: "",
+			wantTail: "starting\nserving",
+		},
+		{
+			name:     "tail",
+			lines:    2,
+			writes:   []string{"1\n2\n3\n4\n"},
+			wantDump: "",
+			wantTail: "3\n4",
+		},
+		{
+			name:     "unterminated line",
+			lines:    2,
+			writes:   []string{"1\n2\n", "3"},
+			wantDump: "",
+			wantTail: "2\n3",
+		},
+		{
+			name:     "panic",
+			lines:    2,
+			writes:   []string{"starting\n", "panic: boom\n\ngoroutine 1 [running]:\n", "main.main()\n"},
+			wantDump: "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n",
+			wantTail: "goroutine 1 [running]:\nmain.main()",
+		},
+		{
+			name:     "split writes",
+			lines:    10,
+			writes:   []string{"pan", "ic: bo", "om\ngorou", "tine 1 [running]:\nmain.ma", "in()"},
+			wantDump: "panic: boom\ngoroutine 1 [running]:\nmain.main()",
+			wantTail: "panic: boom\ngoroutine 1 [running]:\nmain.main()",
+		},
+		{
+			name:     "last dump wins",
+			lines:    10,
+			writes:   []string{"panic: logged\nrecovered\n", "fatal error: concurrent map writes\n"},
+			wantDump: "fatal error: concurrent map writes\n",
+			wantTail: "panic: logged\nrecovered\nfatal error: concurrent map writes",
+		},
+		{
+			name:     "long line",
+			lines:    3,
+			writes:   []string{strings.Repeat("#", maxLineSize), strings.Repeat("#", maxLineSize/2), "\n"},
+			wantDump: "",
+			wantTail: strings.Repeat("#", maxLineSize) + "\n" + strings.Repeat("#", maxLineSize/2),
+		},
+		{
+			name:     "signal",
+			lines:    10,
+			writes:   []string{"SIGSEGV: segmentation violation\nPC=0x0 m=0\n"},
+			wantDump: "SIGSEGV: segmentation violation\nPC=0x0 m=0\n",
+			wantTail: "SIGSEGV: segmentation violation\nPC=0x0 m=0",
+		},
+		{
+			name:     "signal log",
+			lines:    10,
+			writes:   []string{"SIGTERM: shutting down\n", "bye\n"},
+			wantDump: "",
+			wantTail: "SIGTERM: shutting down\nbye",
+		},
+		{
+			name:     "signal during dump",
+			lines:    10,
+			writes:   []string{"SIGQUIT: quit\nPC=0x46c1a1 m=0 sigcode=0\n\ngoroutine 0 [idle]:\n"},
+			wantDump: "SIGQUIT: quit\nPC=0x46c1a1 m=0 sigcode=0\n\ngoroutine 0 [idle]:\n",
+			wantTail: "SIGQUIT: quit\nPC=0x46c1a1 m=0 sigcode=0\n\ngoroutine 0 [idle]:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := newCrashWriter(&out, tt.lines)
+			var all string
+			for _, w := range tt.writes {
+				if _, err := c.Write([]byte(w)); err != nil {
+					t.Fatal(err)
+				}
+				all += w
+			}
+			if out.String() != all {
+				t.Errorf("written = %q, want %q", out.String(), all)
+			}
+			if got := string(c.Dump()); got != tt.wantDump {
+				t.Errorf("Dump() = %q, want %q", got, tt.wantDump)
+			}
+			if got := c.Tail(); got != tt.wantTail {
+				t.Errorf("Tail() = %q, want %q", got, tt.wantTail)
+			}
+		})
+	}
+}
+
+func Test_crashWriter_partial(t *testing.T) {
+	c := newCrashWriter(ioutil.Discard, 1)
+	chunk := []byte(strings.Repeat("=", 1000))
+	for i := 0; i < 1000; i++ {
+		c.Write(chunk)
+	}
+	if n := len(c.partial); n >= maxLineSize {
+		t.Errorf("len(partial) = %d, want less than %d", n, maxLineSize)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// The ANSI escape sequences of the console output.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiBlue    = "\x1b[34m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+var levelColors = map[string]string{
+	string(DebugLevel):    ansiDim,
+	string(InfoLevel):     ansiBlue,
+	string(WarnLevel):     ansiYellow,
+	string(ErrorLevel):    ansiRed,
+	string(CriticalLevel): ansiBold + ansiMagenta,
+}
+
+type consoleTransport struct {
+	mu    sync.Mutex
+	w     io.Writer
+	color bool
+}
+
+// NewConsoleTransport creates the Transport which prints the payload to w in the
+// human-readable form instead of sending it: the level, title, exception class
+// and message, the stack with the in-app frames highlighted, the custom data and
+// the person. If color is true, the output is colorized by the ANSI escape sequences.
+func NewConsoleTransport(w io.Writer, color bool) Transport {
+	return &consoleTransport{w: w, color: color}
+}
+
+// Send implements Transport.
+func (t *consoleTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	var buf bytes.Buffer
+	t.format(&buf, payload)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return localResponse(payload), nil
+}
+
+// paint returns s in the color if the output is colorized.
+func (t *consoleTransport) paint(color, s string) string {
+	if !t.color || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func (t *consoleTransport) format(w *bytes.Buffer, payload *api.Payload) {
+	d := payload.Data
+	if d == nil {
+		fmt.Fprintf(w, "%s <no data>\n\n", t.paint(ansiDim, "[rollbar]"))
+		return
+	}
+
+	fmt.Fprintf(w, "%s %s %s\n", t.paint(ansiDim, "[rollbar]"),
+		t.paint(levelColors[d.Level], strings.ToUpper(d.Level)), t.paint(ansiBold, d.Title))
+	if d.UUID != "" {
+		fmt.Fprintf(w, "  %s %s\n", t.paint(ansiDim, "uuid:"), d.UUID)
+	}
+
+	if b := d.Body; b != nil {
+		if b.Trace != nil {
+			t.formatTrace(w, b.Trace)
+		}
+		for _, trace := range b.TraceChain {
+			fmt.Fprintf(w, "  %s\n", t.paint(ansiDim, "caused by:"))
+			t.formatTrace(w, trace)
+		}
+		if b.Message != nil && b.Message.Body != "" {
+			fmt.Fprintf(w, "  %s %s\n", t.paint(ansiDim, "message:"), b.Message.Body)
+		}
+		if b.CrashReport != nil {
+			fmt.Fprintf(w, "  %s\n", t.paint(ansiDim, "crash report:"))
+			for _, line := range strings.Split(strings.TrimRight(b.CrashReport.Raw, "\n"), "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+
+	if len(d.Custom) > 0 {
+		fmt.Fprintf(w, "  %s\n", t.paint(ansiDim, "custom:"))
+		keys := make([]string, 0, len(d.Custom))
+		width := 0
+		for k := range d.Custom {
+			keys = append(keys, k)
+			if len(k) > width {
+				width = len(k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "    %s %s\n", t.paint(ansiCyan, padRight(k+":", width+1)), formatValue(d.Custom[k]))
+		}
+	}
+
+	if p := d.Person; p != nil {
+		fmt.Fprintf(w, "  %s %s", t.paint(ansiDim, "person:"), p.ID)
+		if p.Username != "" {
+			fmt.Fprintf(w, " %s", p.Username)
+		}
+		if p.Email != "" {
+			fmt.Fprintf(w, " <%s>", p.Email)
+		}
+		w.WriteByte('\n')
+	}
+	w.WriteByte('\n')
+}
+
+// formatTrace prints the exception and the stack with the aligned columns.
+func (t *consoleTransport) formatTrace(w *bytes.Buffer, trace *api.Trace) {
+	if e := trace.Exception; e != nil {
+		fmt.Fprintf(w, "  %s: %s\n", t.paint(ansiRed, e.Class), e.Message)
+		if e.Description != "" {
+			fmt.Fprintf(w, "  %s\n", e.Description)
+		}
+	}
+
+	width := 0
+	for _, f := range trace.Frames {
+		if len(f.Method) > width {
+			width = len(f.Method)
+		}
+	}
+	for _, f := range trace.Frames {
+		method := padRight(f.Method, width)
+		location := f.Filename + ":" + strconv.Itoa(f.Lineno)
+		if f.InApp {
+			fmt.Fprintf(w, "    %s  %s\n", t.paint(ansiBold+ansiGreen, method), t.paint(ansiGreen, location))
+		} else {
+			fmt.Fprintf(w, "    %s  %s\n", t.paint(ansiDim, method), t.paint(ansiDim, location))
+		}
+	}
+}
+
+func padRight(s string, n int) string {
+	if len(s) >= n {
+		return s
+	}
+	return s + strings.Repeat(" ", n-len(s))
+}
+
+// formatValue formats the custom data value in JSON, or with fmt if it is not encodable.
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// dryRunDefault reports whether the payload should be printed to the console
+// instead of being sent, by the ROLLBAR_DRY_RUN environment variable, or the
+// "development" environment given explicitly. The default environment and a
+// given endpoint or transport never enable the dry run, not to drop the items
+// silently.
+func (c *httpClient) dryRunDefault() bool {
+	if v, err := strconv.ParseBool(os.Getenv("ROLLBAR_DRY_RUN")); err == nil {
+		return v
+	}
+	return c.environmentSet && c.environment == "development" && !c.endpointSet && c.transport == nil
+}
+
+// isTerminal reports whether f is a character device, such as a terminal,
+// and the NO_COLOR environment variable is not set.
+func isTerminal(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func TestNewConsoleTransport(t *testing.T) {
+	payload := &api.Payload{
+		Data: &api.Data{
+			Level: "error",
+			Title: "open config.yml: no such file",
+			UUID:  "6ba7b810-9dad-41d1-80b4-00c04fd430c8",
+			Body: &api.Body{
+				Trace: &api.Trace{
+					Frames: []*api.Frame{
+						{Filename: "github.com/example/app/config.go", Lineno: 12, Method: "app.load", InApp: true},
+						{Filename: "runtime/proc.go", Lineno: 271, Method: "runtime.main"},
+					},
+					Exception: &api.Exception{Class: "*fs.PathError (ENOENT)", Message: "open config.yml: no such file"},
+				},
+			},
+			Custom: map[string]interface{}{"retries": 3, "path": "config.yml"},
+			Person: &api.Person{ID: "1", Username: "gopher", Email: "gopher@example.com"},
+		},
+	}
+
+	var buf bytes.Buffer
+	res, err := NewConsoleTransport(&buf, false).Send(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Result.UUID != payload.Data.UUID {
+		t.Errorf("Result.UUID = %q, want %q", res.Result.UUID, payload.Data.UUID)
+	}
+
+	want := `[rollbar] ERROR open config.yml: no such file
+  uuid: 6ba7b810-9dad-41d1-80b4-00c04fd430c8
+  *fs.PathError (ENOENT): open config.yml: no such file
+    app.load      github.com/example/app/config.go:12
+    runtime.main  runtime/proc.go:271
+  custom:
+    path:    config.yml
+    retries: 3
+  person: 1 gopher <gopher@example.com>
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	if _, err := NewConsoleTransport(&buf, true).Send(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, ansiBold+ansiGreen+"app.load    "+ansiReset) {
+		t.Errorf("output = %q, want the in-app frame highlighted", got)
+	}
+}
+
+func Test_dryRunDefault(t *testing.T) {
+	defer os.Setenv("ROLLBAR_DRY_RUN", os.Getenv("ROLLBAR_DRY_RUN"))
+
+	tests := []struct {
+		name    string
+		env     string
+		options []Option
+		want    bool
+	}{
+		{name: "default environment", env: "", want: false},
+		{name: "development", env: "", options: []Option{WithEnvironment("development")}, want: true},
+		{name: "development with endpoint", env: "", options: []Option{WithEnvironment("development"), WithEndpoint("http://localhost:8080")}, want: false},
+		{name: "production", env: "", options: []Option{WithEnvironment("production")}, want: false},
+		{name: "ROLLBAR_DRY_RUN", env: "1", options: []Option{WithEnvironment("production")}, want: true},
+		{name: "ROLLBAR_DRY_RUN with endpoint", env: "1", options: []Option{WithEndpoint("http://localhost:8080")}, want: true},
+		{name: "ROLLBAR_DRY_RUN disabled", env: "false", options: []Option{WithEnvironment("development")}, want: false},
+		{name: "development with transport", env: "", options: []Option{WithEnvironment("development"), WithTransport(NewNopTransport())}, want: false},
+		{name: "ROLLBAR_DRY_RUN with transport", env: "1", options: []Option{WithTransport(NewNopTransport())}, want: true},
+	}
+	for _, tt := range tests {
+		os.Setenv("ROLLBAR_DRY_RUN", tt.env)
+		c := &httpClient{environment: "development"}
+		for _, opt := range tt.options {
+			opt(c)
+		}
+		if got := c.dryRunDefault(); got != tt.want {
+			t.Errorf("%s: dryRunDefault() with ROLLBAR_DRY_RUN=%q = %v, want %v", tt.name, tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// crashHeader is the first line of the crash output file. It records the
+// metadata of the process, which is reported along with its crash.
+const crashHeader = "go-rollbar crash output:"
+
+// crashReportTimeout is the timeout of reporting the previous crash on start.
+const crashReportTimeout = 30 * time.Second
+
+// ReportCrash reports the crash recorded in the crash output file at path,
+// which was configured by WithCrashOutput, as a critical item.
+//
+// ReportCrash is intended to be called from a watcher process. It returns a nil
+// response and error if the file has no crash dump.
+//
+// After the crash is reported, the file is renamed with the ".reported" suffix,
+// replacing the previously reported one, so that the crash is not reported again
+// by the next call. If the report fails, the file is left for the retry.
+func ReportCrash(ctx context.Context, cl Client, path string) (*api.Response, error) {
+	d, err := readCrash(path)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	res, err := cl.Critical(d).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(path, path+crashReportedExt); err != nil {
+		return res, errors.Wrap(err, "failed to rename reported crash output")
+	}
+	return res, nil
+}
+
+// readCrash reads the crash output file at path. It returns a nil Dump if the
+// file does not exist or has no crash dump.
+func readCrash(path string) (*Dump, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read crash output")
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to stat crash output")
+	}
+
+	return parseCrash(data, fi.ModTime()), nil
+}
+
+// parseCrash parses the crash output written at modTime.
+func parseCrash(data []byte, modTime time.Time) *Dump {
+	d, err := ParseDump(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	d.Time = modTime
+
+	header := data
+	if i := bytes.IndexByte(header, '\n'); i >= 0 {
+		header = header[:i]
+	}
+	if s := string(header); strings.HasPrefix(s, crashHeader) {
+		for _, field := range strings.Fields(strings.TrimPrefix(s, crashHeader)) {
+			if kv := strings.SplitN(field, "=", 2); len(kv) == 2 && kv[0] == "code_version" {
+				d.CodeVersion = kv[1]
+			}
+		}
+	}
+
+	return d
+}
+
+// crashReportedExt is the extension of the crash output file reported by ReportCrash.
+const crashReportedExt = ".reported"
+
+// crashPendingExt is the extension of the crash output files moved aside, until
+// the crash is reported.
+const crashPendingExt = ".pending"
+
+// captureCrash reports the crash recorded by the previous process, and
+// configures the runtime to write the crash output of this process to the file.
+//
+// The previous crash output is moved aside before the file is truncated for this
+// process, and removed only after its crash is reported. If the report fails, it
+// is retried by the next process.
+func (c *httpClient) captureCrash(cl Client) {
+	ctx := context.Background()
+
+	if _, err := os.Stat(c.crashOutput); err == nil {
+		pending := fmt.Sprintf("%s.%d%s", c.crashOutput, time.Now().UnixNano(), crashPendingExt)
+		if err := os.Rename(c.crashOutput, pending); err != nil {
+			c.logger.Infof(ctx, "failed to move previous crash output: %v\n", err)
+			return // not to truncate the crash which is not reported yet
+		}
+	}
+
+	if err := c.setCrashOutput(); err != nil {
+		c.logger.Infof(ctx, "failed to set crash output: %v\n", err)
+	}
+
+	pendings, err := filepath.Glob(c.crashOutput + ".*" + crashPendingExt)
+	if err != nil {
+		c.logger.Infof(ctx, "failed to list previous crash output: %v\n", err)
+		return
+	}
+	for _, pending := range pendings {
+		prev, err := readCrash(pending)
+		if err != nil {
+			c.logger.Infof(ctx, "failed to read previous crash output: %v\n", err)
+			continue
+		}
+		if prev == nil {
+			os.Remove(pending)
+			continue
+		}
+		go func(pending string, prev *Dump) {
+			ctx, cancel := context.WithTimeout(ctx, crashReportTimeout)
+			defer cancel()
+			if _, err := cl.Critical(prev).Do(ctx); err != nil {
+				c.logger.Infof(ctx, "failed to report previous crash: %v\n", err)
+				return
+			}
+			os.Remove(pending)
+		}(pending, prev)
+	}
+}
+
+// createCrashOutput truncates the crash output file and writes the header.
+func (c *httpClient) createCrashOutput() (*os.File, error) {
+	f, err := os.OpenFile(c.crashOutput, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create crash output")
+	}
+	if _, err := fmt.Fprintf(f, "%s pid=%d code_version=%s\n", crashHeader, os.Getpid(), c.version()); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "failed to write crash output header")
+	}
+	return f, nil
+}
@@ -0,0 +1,25 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+// +build go1.23
+
+package rollbar
+
+import (
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+)
+
+// setCrashOutput configures the runtime to write the crash output to the file.
+func (c *httpClient) setCrashOutput() error {
+	f, err := c.createCrashOutput()
+	if err != nil {
+		return err
+	}
+	defer f.Close() // SetCrashOutput duplicates the file descriptor
+
+	return errors.Wrap(debug.SetCrashOutput(f, debug.CrashOptions{}), "failed to set crash output")
+}
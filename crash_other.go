@@ -0,0 +1,17 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !go1.23
+// +build !go1.23
+
+package rollbar
+
+import (
+	"github.com/pkg/errors"
+)
+
+// setCrashOutput is not supported before Go 1.23, which added debug.SetCrashOutput.
+func (c *httpClient) setCrashOutput() error {
+	return errors.New("crash output requires Go 1.23 or later")
+}
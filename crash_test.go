@@ -0,0 +1,156 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func Test_parseCrash(t *testing.T) {
+	modTime := time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		data            string
+		wantDump        bool
+		wantCodeVersion string
+	}{
+		{
+			name:            "crash",
+			data:            crashHeader + " pid=42 code_version=2.1.12\n" + testPanicDump,
+			wantDump:        true,
+			wantCodeVersion: "2.1.12",
+		},
+		{
+			name: "no crash",
+			data: crashHeader + " pid=42 code_version=2.1.12\n",
+		},
+		{
+			name:     "no header",
+			data:     testPanicDump,
+			wantDump: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := parseCrash([]byte(tt.data), modTime)
+			if (d != nil) != tt.wantDump {
+				t.Fatalf("parseCrash() = %v, wantDump %v", d, tt.wantDump)
+			}
+			if d == nil {
+				return
+			}
+			if d.CodeVersion != tt.wantCodeVersion {
+				t.Errorf("parseCrash().CodeVersion = %q, want %q", d.CodeVersion, tt.wantCodeVersion)
+			}
+			if !d.Time.Equal(modTime) {
+				t.Errorf("parseCrash().Time = %v, want %v", d.Time, modTime)
+			}
+		})
+	}
+}
+
+func Test_readCrash_notExist(t *testing.T) {
+	d, err := readCrash(filepath.Join(t.TempDir(), "crash.out"))
+	if d != nil || err != nil {
+		t.Errorf("readCrash() = %v, %v, want nil, nil", d, err)
+	}
+}
+
+// failTransport fails to send the payloads, and notifies the attempts.
+type failTransport chan<- *api.Payload
+
+func (t failTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	t <- payload
+	return nil, errTest
+}
+
+func Test_captureCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crash.out")
+	if err := ioutil.WriteFile(path, []byte(crashHeader+" pid=42 code_version=2.1.12\n"+testPanicDump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pendings := func() []string {
+		names, err := filepath.Glob(path + ".*" + crashPendingExt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+
+	// the report fails, and the crash is kept for the next process
+	ch := make(chan *api.Payload, 1)
+	New("xxxxxxxxxxxxxxxx", WithCrashOutput(path), WithTransport(failTransport(ch)))
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("previous crash is not reported")
+	}
+	if got := pendings(); len(got) != 1 {
+		t.Fatalf("pending crash outputs = %v, want 1 file", got)
+	}
+
+	// the report succeeds, and the crash is removed
+	ch = make(chan *api.Payload, 1)
+	New("xxxxxxxxxxxxxxxx", WithCrashOutput(path), WithTransport(NewChanTransport(ch)))
+	select {
+	case payload := <-ch:
+		if got, want := payload.Data.CodeVersion, "2.1.12"; got != want {
+			t.Errorf("CodeVersion = %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("previous crash is not reported")
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(pendings()) > 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending crash outputs = %v, want removed", pendings())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReportCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crash.out")
+	crash := []byte(crashHeader + " pid=42 code_version=2.1.12\n" + testPanicDump)
+	if err := ioutil.WriteFile(path, crash, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the report fails, and the crash is kept for the retry
+	ch := make(chan *api.Payload, 1)
+	if _, err := ReportCrash(context.Background(), New("xxxxxxxxxxxxxxxx", WithTransport(failTransport(ch))), path); err == nil {
+		t.Fatal("ReportCrash() error = nil, want the transport error")
+	}
+	<-ch
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("crash output is not kept: %v", err)
+	}
+
+	// the report succeeds, and the crash is not reported again
+	ch = make(chan *api.Payload, 2)
+	cl := New("xxxxxxxxxxxxxxxx", WithTransport(NewChanTransport(ch)))
+	for i, want := range []bool{true, false} {
+		res, err := ReportCrash(context.Background(), cl, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res != nil; got != want {
+			t.Errorf("ReportCrash() #%d reported = %v, want %v", i, got, want)
+		}
+	}
+	if len(ch) != 1 {
+		t.Errorf("reported %d times, want 1", len(ch))
+	}
+	if b, err := ioutil.ReadFile(path + crashReportedExt); err != nil || string(b) != string(crash) {
+		t.Errorf("reported crash output = %q, %v, want %q", b, err, crash)
+	}
+}
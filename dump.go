@@ -0,0 +1,263 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// Goroutine represents a goroutine parsed from a Go traceback.
+type Goroutine struct {
+	// ID is the goroutine id.
+	ID int
+	// State is the goroutine state, such as "running" or "chan receive, 2 minutes".
+	State string
+	// Stack is the goroutine call stack, the most recent call first.
+	Stack Stack
+	// CreatedBy is the go statement frame which started the goroutine, if any.
+	CreatedBy *api.Frame
+	// Elided reports whether the runtime elided some frames of the stack.
+	Elided bool
+}
+
+// Dump represents a parsed Go panic, fatal error or goroutine dump.
+type Dump struct {
+	// Kind is the kind of crash. One of "panic", "fatal error", the name of the
+	// signal such as "SIGQUIT", or empty for a plain goroutine dump.
+	Kind string
+	// Message is the panic value or fatal error message.
+	Message string
+	// Signal is the signal line printed by the runtime, such as
+	// "[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x48f4a6]".
+	Signal string
+	// Goroutines is the list of dumped goroutines. The first one is the crashed goroutine.
+	Goroutines []*Goroutine
+	// Raw is the raw crash output. It is sent as a crash report when the dump has no goroutines.
+	Raw string
+	// Time is the time of the crash, if known. It is sent as the occurrence timestamp.
+	Time time.Time
+	// CodeVersion is the version of the crashed application code, if known. It
+	// is sent instead of the client code version.
+	CodeVersion string
+}
+
+const (
+	panicPrefix = "panic: "
+	fatalPrefix = "fatal error: "
+)
+
+var (
+	reGoroutineHeader = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[(.*)\]:$`)
+	reSignalHeader    = regexp.MustCompile(`^(SIG[A-Z0-9]+): (.*)$`)
+	reFramesElided    = regexp.MustCompile(`^\.\.\.(?:additional|\d+) frames elided\.\.\.$`)
+)
+
+// ParseDump parses the Go panic, fatal error or goroutine dump text read from r.
+//
+// Any output which precedes the dump is skipped. ParseDump returns an error
+// if r contains no goroutine traceback.
+func ParseDump(r io.Reader) (*Dump, error) {
+	var (
+		d        Dump
+		g        *Goroutine
+		frame    *api.Frame // function line which waits for its file line
+		function string     // fully qualified function name of frame
+		inCrash  bool       // reading continuation lines of the crash message
+	)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		if d.Kind == "" && len(d.Goroutines) == 0 {
+			switch {
+			case strings.HasPrefix(line, panicPrefix):
+				d.Kind, d.Message, inCrash = "panic", strings.TrimPrefix(line, panicPrefix), true
+				continue
+			case strings.HasPrefix(line, fatalPrefix):
+				d.Kind, d.Message, inCrash = "fatal error", strings.TrimPrefix(line, fatalPrefix), true
+				continue
+			}
+			if m := reSignalHeader.FindStringSubmatch(line); m != nil {
+				d.Kind, d.Message, inCrash = m[1], m[2], true
+				continue
+			}
+		}
+
+		if m := reGoroutineHeader.FindStringSubmatch(line); m != nil {
+			inCrash, frame = false, nil
+			id, _ := strconv.Atoi(m[1])
+			g = &Goroutine{ID: id, State: m[2]}
+			d.Goroutines = append(d.Goroutines, g)
+			continue
+		}
+
+		if inCrash {
+			switch {
+			case line == "":
+				inCrash = false
+			case strings.HasPrefix(line, "[signal "):
+				d.Signal = line
+				inCrash = false
+			default:
+				d.Message += "\n" + line
+			}
+			continue
+		}
+
+		if g == nil {
+			continue
+		}
+
+		switch {
+		case line == "":
+			// blank line terminates the goroutine
+			g, frame = nil, nil
+		case strings.HasPrefix(line, "\t"):
+			if frame == nil {
+				continue // e.g. "goroutine running on other thread; stack unavailable"
+			}
+			file, lineno := parseFileLine(line)
+			// the main module of the dumped binary is unknown
+			frame.Filename, frame.InApp = normalizeModuleFilename(function, file, "", "")
+			frame.Lineno = lineno
+			frame = nil
+		case reFramesElided.MatchString(line):
+			g.Elided = true
+		case strings.HasPrefix(line, "created by "):
+			function = strings.TrimPrefix(line, "created by ")
+			if i := strings.Index(function, " in goroutine "); i >= 0 {
+				function = function[:i]
+			}
+			frame = &api.Frame{Method: shortFuncName(function)}
+			g.CreatedBy = frame
+		case strings.HasSuffix(line, ")"):
+			function = trimArgs(line)
+			frame = &api.Frame{Method: shortFuncName(function)}
+			g.Stack = append(g.Stack, frame)
+		default:
+			// trailing output such as "exit status 2"
+			g, frame = nil, nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read dump")
+	}
+
+	if len(d.Goroutines) == 0 {
+		return nil, errors.New("no goroutine found in dump")
+	}
+
+	return &d, nil
+}
+
+// Error implements the error interface.
+func (d *Dump) Error() string {
+	switch {
+	case d.Kind != "":
+		return d.Kind + ": " + d.Message
+	case d.Message != "":
+		return d.Message
+	default:
+		return "goroutine dump"
+	}
+}
+
+// Stack returns the stack of the crashed goroutine, including the frame
+// which created it.
+func (d *Dump) Stack() Stack {
+	if len(d.Goroutines) == 0 {
+		return nil
+	}
+	g := d.Goroutines[0]
+	stack := make(Stack, 0, len(g.Stack)+1)
+	stack = append(stack, g.Stack...)
+	if g.CreatedBy != nil {
+		stack = append(stack, g.CreatedBy)
+	}
+	return stack
+}
+
+// Trace returns the rollbar trace of the crashed goroutine.
+func (d *Dump) Trace() *api.Trace {
+	class := d.Kind
+	if class == "" {
+		class = "goroutine dump"
+	}
+	return &api.Trace{
+		Frames: d.Stack(),
+		Exception: &api.Exception{
+			Class:       class,
+			Message:     d.Message,
+			Description: d.Signal,
+		},
+	}
+}
+
+// body returns the rollbar item body of the dump.
+func (d *Dump) body() *api.Body {
+	if len(d.Goroutines) == 0 {
+		return &api.Body{
+			CrashReport: &api.CrashReport{
+				Raw: d.Raw,
+			},
+		}
+	}
+	return &api.Body{
+		Trace: d.Trace(),
+	}
+}
+
+// trimArgs trims the argument list from the traceback function line, such as
+// "main.(*T).M(0xc000010000, {0x4b2a3c, 0x3})" or "main.Map[...](...)".
+func trimArgs(line string) string {
+	if !strings.HasSuffix(line, ")") {
+		return line
+	}
+	depth := 0
+	for i := len(line) - 1; i >= 0; i-- {
+		switch line[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// parseFileLine parses the traceback file line such as
+// "\t/usr/local/go/src/net/http/server.go:3285 +0x4b4".
+func parseFileLine(line string) (string, int) {
+	line = strings.TrimPrefix(line, "\t")
+	if i := strings.Index(line, " +0x"); i >= 0 {
+		line = line[:i]
+	}
+	if i := strings.Index(line, " fp=0x"); i >= 0 {
+		line = line[:i]
+	}
+
+	i := strings.LastIndex(line, ":")
+	if i < 0 {
+		return line, 0
+	}
+	lineno, err := strconv.Atoi(line[i+1:])
+	if err != nil {
+		return line, 0
+	}
+	return line[:i], lineno
+}
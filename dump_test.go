@@ -0,0 +1,142 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+const testPanicDump = `starting server...
+panic: runtime error: index out of range [6] with length 0 [recovered]
+	panic: second panic
+
+goroutine 7 [running]:
+main.Map[...](...)
+	/tmp/app/main.go:7
+main.(*T).M(0xc000010000, {0x4b2a3c, 0x3})
+	/tmp/app/main.go:8 +0x1d
+main.main.func1()
+	/tmp/app/main.go:11 +0xbf
+created by main.main in goroutine 1
+	/tmp/app/main.go:11 +0x76
+
+goroutine 1 [chan receive, 2 minutes]:
+main.main()
+	/tmp/app/main.go:12 +0x85
+exit status 2
+`
+
+const testFatalDump = `fatal error: unexpected signal during runtime execution
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x48f4a6]
+
+runtime stack:
+runtime.throw({0x4b8a2e?, 0x0?})
+	/usr/local/go/src/runtime/panic.go:1023 +0x5c fp=0x7ffd2c8b8e58 sp=0x7ffd2c8b8e28 pc=0x43579c
+
+goroutine 18 gp=0xc000102a80 m=3 mp=0xc000080e08 [running]:
+github.com/example/app/internal/store.(*DB).Get(0x0)
+	/home/ci/app/internal/store/db.go:42 +0x26 fp=0xc00005af50 sp=0xc00005af38 pc=0x48f4a6
+...additional frames elided...
+created by net/http.(*Server).Serve
+	/usr/local/go/src/net/http/server.go:3285 +0x4b4
+`
+
+func TestParseDump(t *testing.T) {
+	tests := []struct {
+		name    string
+		dump    string
+		want    *Dump
+		wantErr bool
+	}{
+		{
+			name: "panic",
+			dump: testPanicDump,
+			want: &Dump{
+				Kind:    "panic",
+				Message: "runtime error: index out of range [6] with length 0 [recovered]\n\tpanic: second panic",
+				Goroutines: []*Goroutine{
+					{
+						ID:    7,
+						State: "running",
+						Stack: Stack{
+							{Filename: "/tmp/app/main.go", Lineno: 7, Method: "main.Map[...]", InApp: true},
+							{Filename: "/tmp/app/main.go", Lineno: 8, Method: "main.(*T).M", InApp: true},
+							{Filename: "/tmp/app/main.go", Lineno: 11, Method: "main.main.func1", InApp: true},
+						},
+						CreatedBy: &api.Frame{Filename: "/tmp/app/main.go", Lineno: 11, Method: "main.main", InApp: true},
+					},
+					{
+						ID:    1,
+						State: "chan receive, 2 minutes",
+						Stack: Stack{
+							{Filename: "/tmp/app/main.go", Lineno: 12, Method: "main.main", InApp: true},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "fatal error",
+			dump: testFatalDump,
+			want: &Dump{
+				Kind:    "fatal error",
+				Message: "unexpected signal during runtime execution",
+				Signal:  "[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x48f4a6]",
+				Goroutines: []*Goroutine{
+					{
+						ID:    18,
+						State: "running",
+						Stack: Stack{
+							{Filename: "github.com/example/app/internal/store/db.go", Lineno: 42, Method: "store.(*DB).Get"},
+						},
+						CreatedBy: &api.Frame{Filename: "net/http/server.go", Lineno: 3285, Method: "http.(*Server).Serve"},
+						Elided:    true,
+					},
+				},
+			},
+		},
+		{
+			name:    "no goroutine",
+			dump:    "exit status 1\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDump(strings.NewReader(tt.dump))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDump() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDump() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDump_Trace(t *testing.T) {
+	d, err := ParseDump(strings.NewReader(testPanicDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace := d.Trace()
+	if got, want := len(trace.Frames), 4; got != want {
+		t.Fatalf("len(Trace().Frames) = %d, want %d", got, want)
+	}
+	if got, want := trace.Frames[3].Method, "main.main"; got != want {
+		t.Errorf("created by frame = %q, want %q", got, want)
+	}
+	if got, want := trace.Exception.Class, "panic"; got != want {
+		t.Errorf("Trace().Exception.Class = %q, want %q", got, want)
+	}
+	if got, want := d.Error(), "panic: runtime error: index out of range [6] with length 0 [recovered]\n\tpanic: second panic"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !plan9
+// +build !plan9
+
+package rollbar
+
+import (
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// errnoNames is the names of the common errno values.
+var errnoNames = map[syscall.Errno]string{
+	syscall.EACCES:        "EACCES",
+	syscall.EADDRINUSE:    "EADDRINUSE",
+	syscall.EADDRNOTAVAIL: "EADDRNOTAVAIL",
+	syscall.EAGAIN:        "EAGAIN",
+	syscall.EBADF:         "EBADF",
+	syscall.ECONNABORTED:  "ECONNABORTED",
+	syscall.ECONNREFUSED:  "ECONNREFUSED",
+	syscall.ECONNRESET:    "ECONNRESET",
+	syscall.EEXIST:        "EEXIST",
+	syscall.EHOSTUNREACH:  "EHOSTUNREACH",
+	syscall.EINTR:         "EINTR",
+	syscall.EINVAL:        "EINVAL",
+	syscall.EISDIR:        "EISDIR",
+	syscall.EMFILE:        "EMFILE",
+	syscall.ENETUNREACH:   "ENETUNREACH",
+	syscall.ENOENT:        "ENOENT",
+	syscall.ENOSPC:        "ENOSPC",
+	syscall.ENOTDIR:       "ENOTDIR",
+	syscall.EPERM:         "EPERM",
+	syscall.EPIPE:         "EPIPE",
+	syscall.EROFS:         "EROFS",
+	syscall.ETIMEDOUT:     "ETIMEDOUT",
+}
+
+func init() {
+	// ENOTEMPTY is the same value as EEXIST on some platforms, such as AIX.
+	if _, ok := errnoNames[syscall.ENOTEMPTY]; !ok {
+		errnoNames[syscall.ENOTEMPTY] = "ENOTEMPTY"
+	}
+}
+
+// errnoName returns the name of the syscall.Errno in the error chain, such as
+// "ENOENT", or "errno N" for the uncommon values.
+func errnoName(err error) (string, bool) {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return "", false
+	}
+	if name, ok := errnoNames[errno]; ok {
+		return name, true
+	}
+	return "errno " + strconv.Itoa(int(errno)), true
+}
@@ -0,0 +1,10 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+// errnoName returns false, plan9 has no errno.
+func errnoName(err error) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !plan9
+// +build !plan9
+
+package rollbar
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_errorClass_errno(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "path error",
+			err:  &os.PathError{Op: "open", Path: "/tmp/x", Err: syscall.ENOENT},
+			want: "fs.PathError (ENOENT)",
+		},
+		{
+			name: "syscall error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			want: "net.OpError (ECONNREFUSED)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err, NormalizeMessage); got != tt.want {
+				t.Errorf("errorClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
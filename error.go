@@ -8,17 +8,81 @@ import (
 	"fmt"
 	"hash/adler32"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
 
+	"github.com/pkg/errors"
 	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
 )
 
+// LevelError is implemented by the errors which specify their own level.
+// The level overrides the level of the Client method which reports the error.
+type LevelError interface {
+	error
+	RollbarLevel() Level
+}
+
+// FingerprintError is implemented by the errors which specify their own fingerprint.
+type FingerprintError interface {
+	error
+	RollbarFingerprint() string
+}
+
+// TitleError is implemented by the errors which specify their own item title.
+type TitleError interface {
+	error
+	RollbarTitle() string
+}
+
+// CustomError is implemented by the errors which carry their own custom data.
+type CustomError interface {
+	error
+	RollbarCustom() map[string]interface{}
+}
+
+// errorOptions fills the options of opt which are not set by the call from the
+// interfaces implemented by opt.err or any error in its chain, the first one wins.
+// It returns the level of the error, or level if the error does not specify it.
+func errorOptions(level Level, opt *callOption) Level {
+	if opt.err == nil {
+		return level
+	}
+
+	var le LevelError
+	if errors.As(opt.err, &le) {
+		if l := le.RollbarLevel(); l != "" {
+			level = l
+		}
+	}
+	var fe FingerprintError
+	if opt.fprint == "" && errors.As(opt.err, &fe) {
+		opt.fprint = fe.RollbarFingerprint()
+	}
+	var te TitleError
+	if opt.title == "" && errors.As(opt.err, &te) {
+		opt.title = te.RollbarTitle()
+	}
+	var ce CustomError
+	if errors.As(opt.err, &ce) {
+		// the call custom data overrides the error one
+		opt.custom = mergeCustom(ce.RollbarCustom(), opt.custom)
+	}
+
+	return level
+}
+
 // errorBody creates a rollbar error body with a given stack trace.
-func errorBody(err error, stack Stack) *api.Body {
+// The message is normalized by normalize to derive the class.
+func errorBody(err error, stack Stack, normalize Normalizer) *api.Body {
+	if d, ok := err.(*Dump); ok {
+		return d.body()
+	}
+
 	message := "<nil>"
 	if err != nil {
 		message = err.Error()
@@ -28,7 +92,7 @@ func errorBody(err error, stack Stack) *api.Body {
 		Trace: &api.Trace{
 			Frames: stack,
 			Exception: &api.Exception{
-				Class:   errorClass(err),
+				Class:   errorClass(err, normalize),
 				Message: message,
 			},
 		},
@@ -36,23 +100,66 @@ func errorBody(err error, stack Stack) *api.Body {
 }
 
 // errorClass expands the function(class) name from err.
-func errorClass(err error) string {
+// The class of the anonymous errors is the checksum of the normalized message.
+//
+// The class is enriched with the detail of the error chain, such as
+// "fs.PathError (ENOENT)", "net.OpError (timeout)" or
+// "url.Error: context.DeadlineExceeded".
+func errorClass(err error, normalize Normalizer) string {
 	if err == nil {
 		return "<nil>"
 	}
+	if ue, ok := err.(*url.Error); ok && ue.Err != nil {
+		return "url.Error: " + errorClass(ue.Err, normalize)
+	}
+
+	class := typeClass(err, normalize)
+	if detail := errorDetail(err); detail != "" && detail != class {
+		class += " (" + detail + ")"
+	}
+	return class
+}
+
+// typeClass returns the class of err from its type.
+func typeClass(err error, normalize Normalizer) string {
+	switch err {
+	case context.Canceled:
+		return "context.Canceled"
+	case context.DeadlineExceeded:
+		return "context.DeadlineExceeded"
+	}
 
 	fn := reflect.TypeOf(err).String()
 	switch fn {
 	case "":
 		return "panic"
 	case "*errors.errorString":
-		checksum := adler32.Checksum([]byte(err.Error()))
+		checksum := adler32.Checksum([]byte(normalize(err.Error())))
 		return fmt.Sprintf("{%x}", checksum)
 	default:
 		return strings.TrimPrefix(fn, "*")
 	}
 }
 
+// errorDetail returns the context error, the errno name or the timeout status
+// of the error chain, in that order.
+func errorDetail(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "context.Canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "context.DeadlineExceeded"
+	}
+	if name, ok := errnoName(err); ok {
+		return name
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return "timeout"
+	}
+	return ""
+}
+
 var (
 	// TODO(zchee): remove it
 	reFilterHeaders = regexp.MustCompile("Authorization")
@@ -0,0 +1,182 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+type domainError struct{}
+
+func (*domainError) Error() string              { return "quota exceeded" }
+func (*domainError) RollbarLevel() Level        { return WarnLevel }
+func (*domainError) RollbarFingerprint() string { return "quota" }
+func (*domainError) RollbarTitle() string       { return "Quota exceeded" }
+func (*domainError) RollbarCustom() map[string]interface{} {
+	return map[string]interface{}{"plan": "free", "limit": 100}
+}
+
+func Test_errorOptions(t *testing.T) {
+	plain := errors.New("plain")
+	wrapped := errors.Wrap(&domainError{}, "failed to upload")
+	tests := []struct {
+		name      string
+		opt       callOption
+		wantLevel Level
+		wantOpt   callOption
+	}{
+		{
+			name:      "plain error",
+			opt:       callOption{err: plain},
+			wantLevel: ErrorLevel,
+			wantOpt:   callOption{err: plain},
+		},
+		{
+			name:      "wrapped",
+			opt:       callOption{err: wrapped},
+			wantLevel: WarnLevel,
+			wantOpt: callOption{
+				err:    wrapped,
+				fprint: "quota",
+				title:  "Quota exceeded",
+				custom: map[string]interface{}{"plan": "free", "limit": 100},
+			},
+		},
+		{
+			name: "call priority",
+			opt: callOption{
+				err:    wrapped,
+				fprint: "call",
+				title:  "Call title",
+				custom: map[string]interface{}{"plan": "pro"},
+			},
+			wantLevel: WarnLevel,
+			wantOpt: callOption{
+				err:    wrapped,
+				fprint: "call",
+				title:  "Call title",
+				custom: map[string]interface{}{"plan": "pro", "limit": 100},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := tt.opt
+			if got := errorOptions(ErrorLevel, &opt); got != tt.wantLevel {
+				t.Errorf("errorOptions() = %v, want %v", got, tt.wantLevel)
+			}
+			if !reflect.DeepEqual(opt, tt.wantOpt) {
+				t.Errorf("errorOptions() opt = %+v, want %+v", opt, tt.wantOpt)
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func Test_errorClass(t *testing.T) {
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "timeout",
+			err:  opErr,
+			want: "net.OpError (timeout)",
+		},
+		{
+			name: "url error",
+			err:  &url.Error{Op: "Get", URL: "http://example.com", Err: opErr},
+			want: "url.Error: net.OpError (timeout)",
+		},
+		{
+			name: "deadline exceeded",
+			err:  context.DeadlineExceeded,
+			want: "context.DeadlineExceeded",
+		},
+		{
+			name: "wrapped canceled",
+			err:  errors.WithMessage(context.Canceled, "failed to query"),
+			want: "errors.withMessage (context.Canceled)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err, NormalizeMessage); got != tt.want {
+				t.Errorf("errorClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var errQuota = errors.New("quota exceeded")
+
+type validationError struct{ field string }
+
+func (e *validationError) Error() string { return "invalid " + e.field }
+
+func TestWithErrorClass(t *testing.T) {
+	c := defaultHTTPClient
+	c.noBuildInfo = true
+	WithErrorClass(errQuota, "QuotaExceeded", WarnLevel)(&c)
+	WithErrorTypeClass((*validationError)(nil), "ValidationError", InfoLevel)(&c)
+
+	tests := []struct {
+		name      string
+		err       error
+		wantClass string
+		wantLevel Level
+	}{
+		{
+			name:      "sentinel",
+			err:       errors.Wrap(errQuota, "upload"),
+			wantClass: "QuotaExceeded",
+			wantLevel: WarnLevel,
+		},
+		{
+			name:      "type",
+			err:       errors.Wrap(&validationError{field: "name"}, "create user"),
+			wantClass: "ValidationError",
+			wantLevel: InfoLevel,
+		},
+		{
+			name:      "level error",
+			err:       errors.Wrap(&domainError{}, "upload"),
+			wantClass: "errors.withStack",
+			wantLevel: WarnLevel,
+		},
+		{
+			name:      "no match",
+			err:       &os.PathError{Op: "open", Path: "/tmp/x", Err: os.ErrNotExist},
+			wantClass: "fs.PathError",
+			wantLevel: ErrorLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := c.payload(ErrorLevel, tt.err, nil)
+			if got := p.Data.Body.Trace.Exception.Class; got != tt.wantClass {
+				t.Errorf("Exception.Class = %q, want %q", got, tt.wantClass)
+			}
+			opt := callOption{err: tt.err}
+			if got := c.errorOptions(ErrorLevel, &opt); got != tt.wantLevel {
+				t.Errorf("level = %v, want %v", got, tt.wantLevel)
+			}
+		})
+	}
+}
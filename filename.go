@@ -0,0 +1,84 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// normalizeFilename rewrites the build machine filename of the function to
+// the path relative to its module, and reports whether it is in the main module
+// of this binary.
+//
+// The files in the module cache are rewritten to "module@version/path", and the
+// other files to "import/path/file.go", such as "net/http/server.go" or
+// "github.com/example/app/handler.go". So the filenames are the same across the
+// build hosts and -trimpath builds.
+func normalizeFilename(function, file string) (string, bool) {
+	var mod, mainPkg string
+	if bi := readBuildInfo(); bi != nil {
+		mod, mainPkg = bi.path, bi.mainPackage
+	}
+	return normalizeModuleFilename(function, file, mod, mainPkg)
+}
+
+// normalizeModuleFilename is like normalizeFilename, but of the binary whose main
+// module path is mod and main package path is mainPkg. They may be empty if unknown.
+func normalizeModuleFilename(function, file, mod, mainPkg string) (string, bool) {
+	file = filepath.ToSlash(file)
+	pkg := funcPackage(function)
+	inApp := pkg == "main" || mod != "" && (pkg == mod || strings.HasPrefix(pkg, mod+"/"))
+
+	if i := strings.LastIndex(file, "/pkg/mod/"); i >= 0 {
+		return unescapeModulePath(file[i+len("/pkg/mod/"):]), inApp
+	}
+	if strings.Contains(file, "@") && !path.IsAbs(file) { // -trimpath module cache file
+		return file, inApp
+	}
+	if pkg == "main" {
+		pkg = mainPkg
+	}
+	if pkg == "" {
+		return file, inApp
+	}
+
+	return pkg + "/" + path.Base(file), inApp
+}
+
+// funcPackage returns the package import path of the fully qualified function
+// name, such as "github.com/example/app/handler.(*Server).ServeHTTP".
+func funcPackage(function string) string {
+	if i := strings.Index(function, "["); i >= 0 { // type parameters
+		function = function[:i]
+	}
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	// the dots in the last element of the package path are escaped, such as "gopkg.in/yaml%2ev2".
+	return strings.Replace(function[:slash+1+dot], "%2e", ".", -1)
+}
+
+// unescapeModulePath unescapes the upper case letters of the module cache path,
+// such as "github.com/!burnt!sushi/toml@v1.2.1/decode.go".
+func unescapeModulePath(p string) string {
+	if !strings.Contains(p, "!") {
+		return p
+	}
+	var b strings.Builder
+	b.Grow(len(p))
+	for i := 0; i < len(p); i++ {
+		if p[i] == '!' && i+1 < len(p) && 'a' <= p[i+1] && p[i+1] <= 'z' {
+			b.WriteByte(p[i+1] - 'a' + 'A')
+			i++
+			continue
+		}
+		b.WriteByte(p[i])
+	}
+	return b.String()
+}
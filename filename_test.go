@@ -0,0 +1,84 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"testing"
+)
+
+func Test_normalizeModuleFilename(t *testing.T) {
+	const (
+		mod     = "github.com/example/app"
+		mainPkg = "github.com/example/app/cmd/app"
+	)
+
+	tests := []struct {
+		name      string
+		function  string
+		file      string
+		want      string
+		wantInApp bool
+	}{
+		{
+			name:     "GOROOT",
+			function: "net/http.(*conn).serve",
+			file:     "/usr/local/go/src/net/http/server.go",
+			want:     "net/http/server.go",
+		},
+		{
+			name:     "module cache",
+			function: "github.com/BurntSushi/toml.(*Decoder).Decode",
+			file:     "/home/ci/go/pkg/mod/github.com/!burnt!sushi/toml@v1.2.1/decode.go",
+			want:     "github.com/BurntSushi/toml@v1.2.1/decode.go",
+		},
+		{
+			name:     "module cache trimpath",
+			function: "github.com/pkg/errors.New",
+			file:     "github.com/pkg/errors@v0.9.1/errors.go",
+			want:     "github.com/pkg/errors@v0.9.1/errors.go",
+		},
+		{
+			name:     "vendor",
+			function: "github.com/pkg/errors.New",
+			file:     "/home/ci/app/vendor/github.com/pkg/errors/errors.go",
+			want:     "github.com/pkg/errors/errors.go",
+		},
+		{
+			name:     "escaped package path",
+			function: "gopkg.in/yaml%2ev2.Unmarshal",
+			file:     "/home/ci/app/vendor/gopkg.in/yaml.v2/yaml.go",
+			want:     "gopkg.in/yaml.v2/yaml.go",
+		},
+		{
+			name:      "main module",
+			function:  "github.com/example/app/internal/store.(*DB).Get[...]",
+			file:      "/home/ci/app/internal/store/db.go",
+			want:      "github.com/example/app/internal/store/db.go",
+			wantInApp: true,
+		},
+		{
+			name:      "main module trimpath",
+			function:  "github.com/example/app/internal/store.(*DB).Get",
+			file:      "github.com/example/app/internal/store/db.go",
+			want:      "github.com/example/app/internal/store/db.go",
+			wantInApp: true,
+		},
+		{
+			name:      "main package",
+			function:  "main.main.func1",
+			file:      "/home/ci/app/cmd/app/main.go",
+			want:      "github.com/example/app/cmd/app/main.go",
+			wantInApp: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotInApp := normalizeModuleFilename(tt.function, tt.file, mod, mainPkg)
+			if got != tt.want || gotInApp != tt.wantInApp {
+				t.Errorf("normalizeModuleFilename(%q, %q) = %q, %v, want %q, %v", tt.function, tt.file, got, gotInApp, tt.want, tt.wantInApp)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Fingerprinter computes the fingerprint of the occurrence from its error class,
+// normalized message and stack. See Normalizer. The occurrences which have the same fingerprint are grouped
+// into the same item.
+//
+// An empty fingerprint leaves the grouping to rollbar.
+type Fingerprinter func(class, message string, stack Stack) string
+
+// FingerprintStack is the default Fingerprinter, which uses the full stack.
+// See Stack.Fingerprint.
+func FingerprintStack(class, message string, stack Stack) string {
+	return stack.Fingerprint()
+}
+
+// FingerprintInApp uses the filenames, functions and line numbers of the in-app
+// frames only, so that the changes of the dependencies and the standard library
+// do not create new items. It falls back to the full stack if no frame is in-app.
+func FingerprintInApp(class, message string, stack Stack) string {
+	var frames Stack
+	for _, frame := range stack {
+		if frame.InApp {
+			frames = append(frames, frame)
+		}
+	}
+	if len(frames) == 0 {
+		frames = stack
+	}
+	return frames.Fingerprint()
+}
+
+// FingerprintFunctions uses the filenames and functions of the stack without the
+// line numbers, so that moving the code within the functions keeps the item.
+func FingerprintFunctions(class, message string, stack Stack) string {
+	return hashFrames(class, stack)
+}
+
+// FingerprintClassTopN returns a Fingerprinter which uses the error class and
+// the filenames and functions of the top n frames.
+func FingerprintClassTopN(n int) Fingerprinter {
+	return func(class, message string, stack Stack) string {
+		if n >= 0 && len(stack) > n {
+			stack = stack[:n]
+		}
+		return hashFrames(class, stack)
+	}
+}
+
+// FingerprintMessage uses the error class and the normalized message, regardless
+// of the stack.
+func FingerprintMessage(class, message string, stack Stack) string {
+	h := crc32.NewIEEE()
+	io.WriteString(h, class)
+	io.WriteString(h, message)
+	return fmt.Sprintf("%x", h.Sum32())
+}
+
+// hashFrames hashes the class and the frames without the line numbers.
+func hashFrames(class string, frames Stack) string {
+	h := crc32.NewIEEE()
+	io.WriteString(h, class)
+	for _, frame := range frames {
+		fmt.Fprintf(h, "%s%s", frame.Filename, frame.Method)
+	}
+	return fmt.Sprintf("%x", h.Sum32())
+}
+
+// fingerprint returns the fingerprint of the occurrence by the client Fingerprinter.
+func (c *httpClient) fingerprint(class, message string, stack Stack) string {
+	if c.fingerprinter == nil {
+		return FingerprintStack(class, message, stack)
+	}
+	return c.fingerprinter(class, message, stack)
+}
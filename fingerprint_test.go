@@ -0,0 +1,124 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import "testing"
+
+func TestFingerprinter(t *testing.T) {
+	stack := Stack{
+		{Filename: "github.com/example/app/db.go", Lineno: 42, Method: "app.(*DB).Get", InApp: true},
+		{Filename: "github.com/example/app/main.go", Lineno: 10, Method: "app.main", InApp: true},
+		{Filename: "runtime/proc.go", Lineno: 271, Method: "runtime.main"},
+	}
+	// moved lines of the in-app frames
+	moved := Stack{
+		{Filename: "github.com/example/app/db.go", Lineno: 43, Method: "app.(*DB).Get", InApp: true},
+		{Filename: "github.com/example/app/main.go", Lineno: 12, Method: "app.main", InApp: true},
+		{Filename: "runtime/proc.go", Lineno: 271, Method: "runtime.main"},
+	}
+	// upgraded runtime
+	upgraded := Stack{
+		{Filename: "github.com/example/app/db.go", Lineno: 42, Method: "app.(*DB).Get", InApp: true},
+		{Filename: "github.com/example/app/main.go", Lineno: 10, Method: "app.main", InApp: true},
+		{Filename: "runtime/proc.go", Lineno: 283, Method: "runtime.main"},
+	}
+	// other caller of the same function
+	caller := Stack{
+		{Filename: "github.com/example/app/db.go", Lineno: 42, Method: "app.(*DB).Get", InApp: true},
+		{Filename: "github.com/example/app/handler.go", Lineno: 20, Method: "app.handle", InApp: true},
+	}
+
+	type occurrence struct {
+		class, message string
+		stack          Stack
+	}
+	base := occurrence{"*os.PathError", "open /tmp/1234: no such file", stack}
+	tests := []struct {
+		name  string
+		f     Fingerprinter
+		same  []occurrence
+		other []occurrence
+	}{
+		{
+			name:  "stack",
+			f:     FingerprintStack,
+			same:  []occurrence{{"*net.OpError", "other", stack}},
+			other: []occurrence{{base.class, base.message, moved}, {base.class, base.message, upgraded}},
+		},
+		{
+			name:  "in-app",
+			f:     FingerprintInApp,
+			same:  []occurrence{{base.class, base.message, upgraded}},
+			other: []occurrence{{base.class, base.message, moved}},
+		},
+		{
+			name:  "functions",
+			f:     FingerprintFunctions,
+			same:  []occurrence{{base.class, base.message, moved}, {base.class, base.message, upgraded}},
+			other: []occurrence{{"*net.OpError", base.message, stack}, {base.class, base.message, caller}},
+		},
+		{
+			name:  "class top 1",
+			f:     FingerprintClassTopN(1),
+			same:  []occurrence{{base.class, base.message, moved}, {base.class, base.message, caller}},
+			other: []occurrence{{"*net.OpError", base.message, stack}},
+		},
+		{
+			name:  "message",
+			f:     FingerprintMessage,
+			same:  []occurrence{{base.class, base.message, caller}},
+			other: []occurrence{{base.class, "open /var/1234: no such file", stack}, {"*net.OpError", base.message, stack}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.f(base.class, base.message, base.stack)
+			if want == "" {
+				t.Fatal("empty fingerprint")
+			}
+			for _, o := range tt.same {
+				if got := tt.f(o.class, o.message, o.stack); got != want {
+					t.Errorf("fingerprint of %v = %q, want %q", o, got, want)
+				}
+			}
+			for _, o := range tt.other {
+				if got := tt.f(o.class, o.message, o.stack); got == want {
+					t.Errorf("fingerprint of %v = %q, want other than %q", o, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFingerprintInApp_noInApp(t *testing.T) {
+	stack := Stack{{Filename: "runtime/proc.go", Lineno: 271, Method: "runtime.main"}}
+	if got, want := FingerprintInApp("", "", stack), stack.Fingerprint(); got != want {
+		t.Errorf("FingerprintInApp() = %q, want %q", got, want)
+	}
+}
+
+func TestWithFingerprinter(t *testing.T) {
+	c := defaultHTTPClient
+	c.noBuildInfo = true
+	WithFingerprinter(func(class, message string, stack Stack) string {
+		return class + ": " + message
+	})(&c)
+
+	payload := c.payload(ErrorLevel, &testError{}, nil)
+	if got, want := payload.Data.Fingerprint, "rollbar.testError: test"; got != want {
+		t.Errorf("Fingerprint = %q, want %q", got, want)
+	}
+
+	var call ErrorCall
+	call.Fingerprint("custom")
+	joinPayload(payload, call.callOption)
+	if got, want := payload.Data.Fingerprint, "custom"; got != want {
+		t.Errorf("Fingerprint = %q, want %q", got, want)
+	}
+}
+
+type testError struct{}
+
+func (*testError) Error() string { return "test" }
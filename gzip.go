@@ -0,0 +1,30 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"compress/gzip"
+	"sync"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// appendGzip appends the gzip compressed data to dst and returns the extended buffer.
+func appendGzip(dst, data []byte) ([]byte, error) {
+	zw := gzipWriterPool.Get().(*gzip.Writer)
+	defer gzipWriterPool.Put(zw)
+	zw.Reset(appendWriter{&dst})
+
+	if _, err := zw.Write(data); err != nil {
+		return dst, err
+	}
+	err := zw.Close()
+	zw.Reset(nil) // not to keep dst in the pool
+	return dst, err
+}
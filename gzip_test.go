@@ -0,0 +1,101 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func TestWithGzip(t *testing.T) {
+	type request struct {
+		encoding string
+		body     []byte
+	}
+	reqc := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		}
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Error(err)
+		}
+		reqc <- request{encoding: r.Header.Get("Content-Encoding"), body: b}
+		w.Write([]byte(`{"err":0,"result":{"id":null,"uuid":"x"}}`))
+	}))
+	defer server.Close()
+
+	const threshold = 4096
+	c := defaultHTTPClient
+	c.token = "xxxxxxxxxxxxxxxx"
+	c.endpoint = server.URL
+	WithGzip(threshold)(&c)
+
+	tests := []struct {
+		name         string
+		message      string
+		wantEncoding string
+	}{
+		{
+			name:         "small",
+			message:      "small",
+			wantEncoding: "",
+		},
+		{
+			name:         "large",
+			message:      strings.Repeat("large ", threshold),
+			wantEncoding: "gzip",
+		},
+	}
+	for i := 0; i < 2; i++ { // reuse the pooled writer
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				payload := c.payload(ErrorLevel, errors.New(tt.message), nil)
+				want, err := json.Marshal(payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				req, err := c.newRequest(payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.wantEncoding == "gzip" && req.ContentLength >= int64(len(want)) {
+					t.Errorf("ContentLength = %d, want less than %d", req.ContentLength, len(want))
+				}
+				var res api.Response
+				if err := c.Do(context.Background(), req, &res); err != nil {
+					t.Fatal(err)
+				}
+
+				got := <-reqc
+				if got.encoding != tt.wantEncoding {
+					t.Errorf("Content-Encoding = %q, want %q", got.encoding, tt.wantEncoding)
+				}
+				if !bytes.Equal(got.body, want) {
+					t.Errorf("body = %s, want %s", got.body, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// IgnoreRule is a rule of the errors which are not reported. See WithIgnore.
+type IgnoreRule struct {
+	desc  string
+	match func(err error, class func() string) bool
+}
+
+// IgnoredError is returned by Call.Do when the error is ignored by the rule.
+type IgnoredError struct {
+	// Err is the ignored error.
+	Err error
+	// Rule is the description of the rule which matched Err.
+	Rule string
+}
+
+// Error implements the error interface.
+func (e *IgnoredError) Error() string {
+	return fmt.Sprintf("rollbar: error ignored by %s: %v", e.Rule, e.Err)
+}
+
+// IgnoreErrors ignores the errors which match any of errs by errors.Is, such as
+// context.Canceled or io.EOF.
+func IgnoreErrors(errs ...error) IgnoreRule {
+	return IgnoreRule{
+		desc: fmt.Sprintf("errors %v", errs),
+		match: func(err error, _ func() string) bool {
+			for _, target := range errs {
+				if errors.Is(err, target) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// IgnoreTypes ignores the errors which have the same type as any of examples in
+// their chain, such as (*MyError)(nil).
+func IgnoreTypes(examples ...error) IgnoreRule {
+	types := make([]reflect.Type, len(examples))
+	for i, example := range examples {
+		types[i] = reflect.TypeOf(example)
+	}
+	return IgnoreRule{
+		desc: fmt.Sprintf("types %v", types),
+		match: func(err error, _ func() string) bool {
+			for ; err != nil; err = errors.Unwrap(err) {
+				typ := reflect.TypeOf(err)
+				for _, t := range types {
+					if typ == t {
+						return true
+					}
+				}
+			}
+			return false
+		},
+	}
+}
+
+// IgnoreClasses ignores the errors whose class matches any of the patterns by
+// path.Match, such as "net.OpError (timeout)" or "url.Error: *".
+func IgnoreClasses(patterns ...string) IgnoreRule {
+	return IgnoreRule{
+		desc: fmt.Sprintf("classes %q", patterns),
+		match: func(_ error, class func() string) bool {
+			cls := class()
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, cls); ok {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// IgnoreMessages ignores the errors whose message matches any of res.
+func IgnoreMessages(res ...*regexp.Regexp) IgnoreRule {
+	return IgnoreRule{
+		desc: fmt.Sprintf("messages %v", res),
+		match: func(err error, _ func() string) bool {
+			message := err.Error()
+			for _, re := range res {
+				if re.MatchString(message) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// IgnoreFunc ignores the errors for which f returns true.
+func IgnoreFunc(f func(error) bool) IgnoreRule {
+	return IgnoreRule{
+		desc: "func",
+		match: func(err error, _ func() string) bool {
+			return f(err)
+		},
+	}
+}
+
+// ignore returns the IgnoredError if err matches any of the ignore rules.
+func (c *httpClient) ignore(err error) error {
+	if err == nil || len(c.ignoreRules) == 0 {
+		return nil
+	}
+
+	var class string
+	classFn := func() string {
+		if class == "" {
+			class = c.errorClass(err)
+		}
+		return class
+	}
+	for _, rule := range c.ignoreRules {
+		if rule.match(err, classFn) {
+			return &IgnoredError{Err: err, Rule: rule.desc}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+func TestWithIgnore(t *testing.T) {
+	var posted int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&posted, 1)
+		w.Write([]byte(`{"err":0,"result":{"id":null,"uuid":"x"}}`))
+	}))
+	defer server.Close()
+
+	cl := New("xxxxxxxxxxxxxxxx",
+		WithEndpoint(server.URL),
+		WithIgnore(
+			IgnoreErrors(context.Canceled, io.EOF),
+			IgnoreTypes((*validationError)(nil)),
+			IgnoreClasses("fs.PathError (ENOENT)"),
+			IgnoreMessages(regexp.MustCompile(`^broken pipe`)),
+			IgnoreFunc(func(err error) bool { return strings.HasSuffix(err.Error(), "by peer") }),
+		),
+	)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantIgnored bool
+	}{
+		{name: "sentinel", err: errors.Wrap(context.Canceled, "query"), wantIgnored: true},
+		{name: "type", err: errors.Wrap(&validationError{field: "name"}, "create"), wantIgnored: true},
+		{name: "class", err: &os.PathError{Op: "open", Path: "/tmp/x", Err: syscall.ENOENT}, wantIgnored: true},
+		{name: "message", err: errors.New("broken pipe"), wantIgnored: true},
+		{name: "func", err: errors.New("connection reset by peer"), wantIgnored: true},
+		{name: "reported", err: errors.New("unexpected"), wantIgnored: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&posted, 0)
+			call := cl.Error(tt.err)
+			if pcs := call.(*ErrorCall).pcs; tt.wantIgnored && pcs != nil {
+				t.Errorf("captured stack of the ignored error")
+			}
+
+			_, err := call.Do(context.Background())
+			var ignored *IgnoredError
+			if got := errors.As(err, &ignored); got != tt.wantIgnored {
+				t.Fatalf("Do() error = %v, wantIgnored %v", err, tt.wantIgnored)
+			}
+			if tt.wantIgnored && ignored.Err != tt.err {
+				t.Errorf("IgnoredError.Err = %v, want %v", ignored.Err, tt.err)
+			}
+			if got, want := atomic.LoadInt32(&posted) != 0, !tt.wantIgnored; got != want {
+				t.Errorf("posted = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Normalizer normalizes the error message by replacing its variable parts, such
+// as the ids, with placeholders. The normalized message is used to derive the
+// class of the anonymous errors and the fingerprint, so that the errors which
+// differ only in those parts are grouped into the same item. The original
+// message is still sent for display.
+type Normalizer func(message string) string
+
+// messageNormalizers is the list of the variable parts replaced by NormalizeMessage, in order.
+// The match is replaced only if valid is nil or reports true.
+var messageNormalizers = []struct {
+	re          *regexp.Regexp
+	placeholder string
+	valid       func(string) bool
+}{
+	// The quote is matched only at the start or after a non-word character, not to
+	// take the apostrophes of the contractions, such as "can't", for the quotes.
+	{re: regexp.MustCompile(`(^|\W)(?:"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`), placeholder: "${1}<str>"},
+	{re: regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z| ?[+-]\d{2}:?\d{2}(?: [A-Z]{3,5}\b)?)?`), placeholder: "<timestamp>"},
+	{re: regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), placeholder: "<uuid>"},
+	{re: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), placeholder: "<ip>"},
+	{re: regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`), placeholder: "<ip>", valid: isIPv6},
+	{re: regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), placeholder: "<hex>"},
+	{re: regexp.MustCompile(`(?i)\b[0-9a-f]{6,}\b`), placeholder: "<hex>", valid: isHexID},
+	{re: regexp.MustCompile(`\d+(?:\.\d+)?`), placeholder: "<num>"},
+}
+
+// NormalizeMessage is the default Normalizer. It replaces the quoted strings,
+// timestamps, UUIDs, IP addresses, hex ids and numbers of message with the
+// "<str>", "<timestamp>", "<uuid>", "<ip>", "<hex>" and "<num>" placeholders.
+func NormalizeMessage(message string) string {
+	if strings.IndexAny(message, "0123456789\"':") < 0 {
+		return message // fast path for the most of the static messages
+	}
+	for _, n := range messageNormalizers {
+		if n.valid == nil {
+			message = n.re.ReplaceAllString(message, n.placeholder)
+			continue
+		}
+		message = replaceValid(n.re, message, n.placeholder, n.valid)
+	}
+	return message
+}
+
+// replaceValid replaces the matches of re in s which are valid and not adjacent
+// to a word character.
+func replaceValid(re *regexp.Regexp, s, placeholder string, valid func(string) bool) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && isWordByte(s[start-1]) || end < len(s) && isWordByte(s[end]) || !valid(s[start:end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(placeholder)
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIPv6(s string) bool {
+	return net.ParseIP(s) != nil
+}
+
+// isHexID reports whether s has both the digits and the hex letters, so the
+// words and the numbers are not taken as the hex ids.
+func isHexID(s string) bool {
+	return strings.IndexAny(s, "0123456789") >= 0 && strings.Trim(s, "0123456789") != ""
+}
+
+// normalize normalizes the message by the client Normalizer.
+func (c *httpClient) normalize(message string) string {
+	if c.normalizer == nil {
+		return NormalizeMessage(message)
+	}
+	return c.normalizer(message)
+}
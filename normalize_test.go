@@ -0,0 +1,68 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"user not found", "user not found"},
+		{"user 123 not found", "user <num> not found"},
+		{"took 1.25s", "took <num>s"},
+		{`unknown field "name" in 'query'`, "unknown field <str> in <str>"},
+		{`"name" is required`, "<str> is required"},
+		{"can't read config: file doesn't exist", "can't read config: file doesn't exist"},
+		{"can't open 'app.conf': permission denied", "can't open <str>: permission denied"},
+		{"user's token isn't valid", "user's token isn't valid"},
+		{"expired at 2017-06-10T12:34:56.789Z", "expired at <timestamp>"},
+		{"expired at 2017-06-10 12:34:56.789 +0900 JST", "expired at <timestamp>"},
+		{"request 6ba7b810-9dad-11d1-80b4-00c04fd430c8 failed", "request <uuid> failed"},
+		{"dial tcp 10.0.0.1:5432: connection refused", "dial tcp <ip>:<num>: connection refused"},
+		{"dial tcp [::1]:8080: connection refused", "dial tcp [<ip>]:<num>: connection refused"},
+		{"dial tcp [2001:db8::68]:443: i/o timeout", "dial tcp [<ip>]:<num>: i/o timeout"},
+		{"invalid pointer 0xc000010000", "invalid pointer <hex>"},
+		{"object 9f86d081884c7d65 deleted", "object <hex> deleted"},
+		{"feedface decoded", "feedface decoded"},
+		{"std::vector at 12:30:00", "std::vector at <num>:<num>:<num>"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeMessage(tt.message); got != tt.want {
+			t.Errorf("NormalizeMessage(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func Test_errorClass_normalize(t *testing.T) {
+	c1 := errorClass(errors.New("user 123 not found"), NormalizeMessage)
+	c2 := errorClass(errors.New("user 456 not found"), NormalizeMessage)
+	if c1 != c2 {
+		t.Errorf("errorClass() = %q and %q, want the same class", c1, c2)
+	}
+	if c3 := errorClass(errors.New("group 123 not found"), NormalizeMessage); c3 == c1 {
+		t.Errorf("errorClass() = %q, want other than %q", c3, c1)
+	}
+}
+
+func TestWithNormalizer(t *testing.T) {
+	c := defaultHTTPClient
+	c.noBuildInfo = true
+	WithNormalizer(func(string) string { return "normalized" })(&c)
+	WithFingerprinter(FingerprintMessage)(&c)
+
+	p1 := c.payload(ErrorLevel, errors.New("user 123 not found"), nil)
+	p2 := c.payload(ErrorLevel, errors.New("group 456 not found"), nil)
+	if p1.Data.Fingerprint != p2.Data.Fingerprint {
+		t.Errorf("Fingerprint = %q and %q, want the same fingerprint", p1.Data.Fingerprint, p2.Data.Fingerprint)
+	}
+	if got, want := p1.Data.Body.Trace.Exception.Message, "user 123 not found"; got != want {
+		t.Errorf("Exception.Message = %q, want %q", got, want)
+	}
+}
@@ -6,6 +6,7 @@ package rollbar
 
 import (
 	"net/http"
+	"reflect"
 )
 
 // Option defines an interface of optional parameters to the
@@ -28,6 +29,7 @@ func WithClient(cl *http.Client) Option {
 func WithEndpoint(s string) Option {
 	return func(c *httpClient) {
 		c.endpoint = s
+		c.endpointSet = true
 	}
 }
 
@@ -61,6 +63,7 @@ func WithDebug(b bool) Option {
 func WithEnvironment(env string) Option {
 	return func(c *httpClient) {
 		c.environment = env
+		c.environmentSet = true
 	}
 }
 
@@ -77,6 +80,8 @@ func WithPlatform(platform string) Option {
 
 // WithCodeVersion is a string, up to 40 characters, describing the version of the application code
 //
+// If not specified, the VCS revision embedded in the binary, or the version of the main module is used.
+//
 // Rollbar understands these formats:
 //  - semantic version (i.e. "2.1.12")
 //  - integer (i.e. "45")
@@ -96,6 +101,9 @@ func WithServerHost(hostname string) Option {
 
 // WithServerRoot is the path to the application code root. Not including the final slash.
 // Used to collapse non-project code when displaying tracebacks.
+//
+// If not specified, the main module path is used, which the filenames of the
+// application code frames are relative to.
 func WithServerRoot(root string) Option {
 	return func(c *httpClient) {
 		c.serverRoot = root
@@ -109,9 +117,191 @@ func WithServerBranch(branch string) Option {
 	}
 }
 
+// WithCallerSkip number of the caller frames to skip.
+//
+// The frames of this package are always skipped, so it is the number of the
+// frames of the caller to skip more, such as the error reporting helper of the application.
+func WithCallerSkip(skip int) Option {
+	return func(c *httpClient) {
+		c.stackskip = skip
+	}
+}
+
 // WithStackSkip number of skip error stacks.
+//
+// The skip counted the frames of this package too, which was 3 by default.
+//
+// Deprecated: The frames of this package are skipped automatically. Use
+// WithCallerSkip with skip - 3.
 func WithStackSkip(skip int) Option {
+	skip -= 3
+	if skip < 0 {
+		skip = 0
+	}
+	return WithCallerSkip(skip)
+}
+
+// WithCrashOutput writes the crash output of unrecovered panics and fatal runtime
+// errors to the file at path, using runtime/debug.SetCrashOutput.
+//
+// Such crashes kill the process before any Call can be done. Instead, the crash
+// recorded in the file is reported as a critical item by the next process which
+// creates the client with the same path, along with the code version it crashed
+// on. A watcher process can report it with ReportCrash as well.
+//
+// It requires Go 1.23 or later.
+func WithCrashOutput(path string) Option {
 	return func(c *httpClient) {
-		c.stackskip = skip
+		c.crashOutput = path
+	}
+}
+
+// WithTelemetrySize number of the last telemetry events attached to every reported item.
+// The default is DefaultTelemetrySize. Zero disables the telemetry.
+func WithTelemetrySize(size int) Option {
+	return func(c *httpClient) {
+		c.telemetrySize = size
+	}
+}
+
+// WithBuildInfo specifies whether the build information embedded in the binary is sent.
+// The default is true.
+//
+// The VCS revision is used as the code version and the server sha, unless WithCodeVersion
+// is specified. The main module path and version, the VCS time and whether the working
+// tree was modified are sent as the "build" custom data.
+func WithBuildInfo(b bool) Option {
+	return func(c *httpClient) {
+		c.noBuildInfo = !b
+	}
+}
+
+// WithStackDepth maximum number of error stack frames. The default is DefaultStackDepth.
+func WithStackDepth(depth int) Option {
+	return func(c *httpClient) {
+		c.stackDepth = depth
+	}
+}
+
+// WithFingerprinter specifies the function which computes the fingerprint of the occurrences.
+// The default is FingerprintStack. The fingerprint can be overridden per call by Call.Fingerprint.
+func WithFingerprinter(f Fingerprinter) Option {
+	return func(c *httpClient) {
+		c.fingerprinter = f
+	}
+}
+
+// WithNormalizer specifies the function which normalizes the error messages before
+// the class of the anonymous errors and the fingerprint are derived from them.
+// The default is NormalizeMessage.
+func WithNormalizer(n Normalizer) Option {
+	return func(c *httpClient) {
+		c.normalizer = n
+	}
+}
+
+// WithErrorClass maps the errors which match the sentinel error by errors.Is to the
+// class and the default level. An empty class or level keeps the default one.
+//
+// The rules are checked in the order of the options, and the first match wins.
+// The level of the LevelError overrides the level of the rule.
+func WithErrorClass(sentinel error, class string, level Level) Option {
+	return func(c *httpClient) {
+		c.classRules = append(c.classRules, classRule{
+			sentinel: sentinel,
+			class:    class,
+			level:    level,
+		})
+	}
+}
+
+// WithErrorTypeClass maps the errors which have the same type as example in their
+// chain, such as (*MyError)(nil), to the class and the default level.
+// See WithErrorClass.
+func WithErrorTypeClass(example error, class string, level Level) Option {
+	return func(c *httpClient) {
+		c.classRules = append(c.classRules, classRule{
+			typ:   reflect.TypeOf(example),
+			class: class,
+			level: level,
+		})
+	}
+}
+
+// WithIgnore specifies the rules of the errors which are not reported, such as
+//
+//	WithIgnore(
+//		IgnoreErrors(context.Canceled, io.EOF, http.ErrAbortHandler),
+//		IgnoreErrors(syscall.EPIPE, syscall.ECONNRESET), // client disconnects
+//	)
+//
+// The Call of the ignored error captures no stack, and its Do returns an
+// *IgnoredError without sending it.
+func WithIgnore(rules ...IgnoreRule) Option {
+	return func(c *httpClient) {
+		c.ignoreRules = append(c.ignoreRules, rules...)
+	}
+}
+
+// WithMaxPayloadSize specifies the byte budget of the encoded payload. The default is DefaultMaxPayloadSize.
+//
+// The larger payload is truncated in order: the request body and the long strings,
+// the middle frames of the long stacks, and then the custom data, the largest key first.
+// The truncation is recorded to the "truncated" notifier diagnostic data. If the payload
+// still exceeds the budget, Call.Do returns ErrPayloadTooLarge. Zero disables the truncation.
+func WithMaxPayloadSize(size int) Option {
+	return func(c *httpClient) {
+		c.maxPayloadSize = size
+	}
+}
+
+// WithGzip specifies the size threshold of the encoded payload in bytes, at or above
+// which the payload is sent gzip compressed with "Content-Encoding: gzip".
+// The default is zero, which disables the compression.
+func WithGzip(threshold int) Option {
+	return func(c *httpClient) {
+		c.gzipThreshold = threshold
+	}
+}
+
+// WithTransport specifies the Transport to send the payload, instead of posting
+// it to the rollbar API. See NewWriterTransport, NewChanTransport and NewNopTransport.
+//
+// The options of the HTTP client, endpoint, payload size and compression do not
+// apply to the transport. Use NewHTTPTransport to wrap the HTTP transport.
+func WithTransport(t Transport) Option {
+	return func(c *httpClient) {
+		c.transport = t
+	}
+}
+
+// WithDryRun specifies whether the items are printed to os.Stderr in the
+// human-readable form instead of being sent. See NewConsoleTransport.
+//
+// If one is not specified, the value specified in ROLLBAR_DRY_RUN environment
+// variable is used. Otherwise the dry run is enabled only if the "development"
+// environment is given by WithEnvironment, without WithEndpoint and WithTransport.
+func WithDryRun(b bool) Option {
+	return func(c *httpClient) {
+		c.dryRun = &b
+	}
+}
+
+// WithRecorder specifies the Recorder to which every outgoing payload is appended,
+// in addition to being sent. The failure of the recording is logged, and does
+// not fail the call.
+func WithRecorder(r *Recorder) Option {
+	return func(c *httpClient) {
+		c.recorder = r
+	}
+}
+
+// WithRelay specifies the relay, such as cmd/rollbar-relay, to which the payload
+// is handed over instead of being posted to the rollbar API. See NewRelayTransport.
+//
+// It is for the short-lived processes, which can not wait for the rollbar API.
+func WithRelay(network, address string) Option {
+	return func(c *httpClient) {
+		c.transport = NewRelayTransport(network, address)
 	}
 }
@@ -0,0 +1,131 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// Recorder appends the payloads to the JSON lines file, one payload per line,
+// for the forensics or the replay. See cmd/rollbar-replay.
+//
+// The access token is not recorded.
+type Recorder struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	f       *os.File
+	size    int64
+	buf     []byte
+}
+
+// NewRecorder opens the file at path to append the payloads.
+//
+// When the file would exceed maxSize bytes, it is rotated: renamed to path.1,
+// the older path.1 to path.2 and so on, keeping at most backups rotated files.
+// A maxSize of zero or less disables the rotation.
+func NewRecorder(path string, maxSize int64, backups int) (*Recorder, error) {
+	r := &Recorder{
+		path:    path,
+		maxSize: maxSize,
+		backups: backups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open record file")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to stat record file")
+	}
+	r.f, r.size = f, fi.Size()
+	return nil
+}
+
+// Record appends the payload to the file.
+func (r *Recorder) Record(payload *api.Payload) error {
+	p := *payload
+	p.AccessToken = ""
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return errors.New("recorder is closed")
+	}
+
+	b, err := p.AppendJSON(r.buf[:0])
+	if err != nil {
+		return errors.Wrap(err, "failed to encode payload")
+	}
+	b = append(b, '\n')
+	if cap(b) <= maxPooledBufferSize {
+		r.buf = b
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(b)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.f.Write(b)
+	r.size += int64(n)
+	return errors.Wrap(err, "failed to write payload")
+}
+
+// rotate renames the file to path.1, shifting the older files.
+func (r *Recorder) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close record file")
+	}
+	r.f = nil
+
+	if r.backups <= 0 {
+		os.Remove(r.path)
+	} else {
+		os.Remove(r.path + "." + strconv.Itoa(r.backups))
+		for i := r.backups - 1; i > 0; i-- {
+			os.Rename(r.path+"."+strconv.Itoa(i), r.path+"."+strconv.Itoa(i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return errors.Wrap(err, "failed to rotate record file")
+		}
+	}
+	return r.open()
+}
+
+// Send implements Transport. It records the payload without sending.
+func (r *Recorder) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	if err := r.Record(payload); err != nil {
+		return nil, err
+	}
+	return localResponse(payload), nil
+}
+
+// Close closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
@@ -0,0 +1,119 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// readRecords returns the UUIDs of the payloads recorded in the file.
+func readRecords(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var uuids []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var payload api.Payload
+		if err := json.Unmarshal(sc.Bytes(), &payload); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if payload.AccessToken != "" {
+			t.Errorf("%s: AccessToken = %q, want not recorded", path, payload.AccessToken)
+		}
+		uuids = append(uuids, payload.Data.UUID)
+	}
+	return uuids
+}
+
+func TestWithRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.jsonl")
+	r, err := NewRecorder(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ch := make(chan *api.Payload, 1)
+	cl := New("xxxxxxxxxxxxxxxx", WithRecorder(r), WithTransport(NewChanTransport(ch)))
+	call := cl.Error(errTest)
+	if _, err := call.Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if payload := <-ch; payload.AccessToken != "xxxxxxxxxxxxxxxx" {
+		t.Errorf("sent AccessToken = %q, want the token", payload.AccessToken)
+	}
+
+	if got := readRecords(t, path); len(got) != 1 || got[0] != call.ID() {
+		t.Errorf("recorded UUIDs = %v, want [%s]", got, call.ID())
+	}
+}
+
+func TestRecorder_rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.jsonl")
+	payload := func(i int) *api.Payload {
+		return &api.Payload{
+			AccessToken: "xxxxxxxxxxxxxxxx",
+			Data:        &api.Data{Environment: "test", UUID: string(rune('a' + i))},
+		}
+	}
+	b, _ := payload(0).AppendJSON(nil)
+	size := int64(len(b) - len(`"access_token":"xxxxxxxxxxxxxxxx"`) + len(`"access_token":""`) + 1)
+
+	// two lines per file
+	r, err := NewRecorder(path, 2*size, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		if err := r.Record(payload(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Record(payload(0)); err == nil {
+		t.Error("Record() error = nil after Close, want error")
+	}
+
+	for name, want := range map[string][]string{
+		path:        {"g"},
+		path + ".1": {"e", "f"},
+		path + ".2": {"c", "d"},
+	} {
+		if got := readRecords(t, name); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: recorded UUIDs = %v, want %v", filepath.Base(name), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists, want at most 2 rotated files", filepath.Base(path))
+	}
+
+	// reopen appends
+	r, err = NewRecorder(path, 2*size, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.Record(payload(7)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readRecords(t, path), []string{"g", "h"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded UUIDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+const (
+	// maxRelayDatagramSize is the upper limit of the UDP datagram payload.
+	// The larger payload is truncated to fit.
+	maxRelayDatagramSize = 65507
+	// relayDialTimeout and relayWriteTimeout bound the time of sending to the relay,
+	// not to block the caller when the relay is down or slow.
+	relayDialTimeout  = 100 * time.Millisecond
+	relayWriteTimeout = 100 * time.Millisecond
+)
+
+type relayTransport struct {
+	network string
+	address string
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  []byte
+}
+
+// NewRelayTransport creates the Transport which hands the payload over to the
+// relay, such as cmd/rollbar-relay, which forwards it to rollbar.
+//
+// The network is "unix" for the Unix domain socket at the path address, or "udp"
+// for the host:port address. The payload is written as a line of JSON on the
+// connection kept open, or as a datagram, truncated to fit. Send returns as soon
+// as the payload is written, without waiting for rollbar. The response has the
+// UUID of the payload.
+//
+// The network "http" or "https" posts the payload to the address URL of the relay.
+func NewRelayTransport(network, address string) Transport {
+	switch network {
+	case "http", "https":
+		return NewHTTPTransport(WithEndpoint(address))
+	}
+	return &relayTransport{network: network, address: address}
+}
+
+// Send implements Transport.
+func (t *relayTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var b []byte
+	var err error
+	if strings.HasPrefix(t.network, "udp") {
+		b, err = marshalPayload(t.buf[:0], payload, maxRelayDatagramSize)
+	} else {
+		b, err = payload.AppendJSON(t.buf[:0])
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode payload")
+	}
+	if cap(b) <= maxPooledBufferSize {
+		t.buf = b
+	}
+
+	reused := t.conn != nil
+	err = t.write(ctx, b)
+	if err != nil && reused { // the relay may have closed the kept connection
+		err = t.write(ctx, b)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send payload to relay")
+	}
+	return localResponse(payload), nil
+}
+
+// write writes b to the connection, dialing it if not connected.
+func (t *relayTransport) write(ctx context.Context, b []byte) error {
+	if t.conn == nil {
+		d := net.Dialer{Timeout: relayDialTimeout}
+		conn, err := d.DialContext(ctx, t.network, t.address)
+		if err != nil {
+			return err
+		}
+		t.conn = conn
+	}
+
+	t.conn.SetWriteDeadline(time.Now().Add(relayWriteTimeout))
+	if _, err := t.conn.Write(b); err != nil {
+		t.conn.Close()
+		t.conn = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func TestWithRelay_unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix socket is not supported: %v", err)
+	}
+	defer ln.Close()
+
+	uuids := make(chan string)
+	go func() {
+		for i := 0; ; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			sc := bufio.NewScanner(conn)
+			sc.Scan()
+			var payload api.Payload
+			if err := json.Unmarshal(sc.Bytes(), &payload); err != nil {
+				t.Error(err)
+			}
+			uuids <- payload.Data.UUID
+			if i == 0 {
+				conn.Close() // the client reconnects
+				continue
+			}
+			for sc.Scan() {
+				if err := json.Unmarshal(sc.Bytes(), &payload); err != nil {
+					t.Error(err)
+				}
+				uuids <- payload.Data.UUID
+			}
+			conn.Close()
+		}
+	}()
+
+	cl := New("xxxxxxxxxxxxxxxx", WithRelay("unix", path))
+	for i := 0; i < 3; i++ {
+		call := cl.Error(errTest)
+		res, err := call.Do(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Result.UUID != call.ID() {
+			t.Errorf("Result.UUID = %q, want %q", res.Result.UUID, call.ID())
+		}
+		if got := <-uuids; got != call.ID() {
+			t.Errorf("relayed UUID = %q, want %q", got, call.ID())
+		}
+	}
+}
+
+func TestWithRelay_udp(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp is not supported: %v", err)
+	}
+	defer pc.Close()
+
+	cl := New("xxxxxxxxxxxxxxxx", WithRelay("udp", pc.LocalAddr().String()))
+	call := cl.Error(errTest).Custom(map[string]interface{}{"large": strings.Repeat("x", maxRelayDatagramSize)})
+	if _, err := call.Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 64<<10)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload api.Payload
+	if err := json.Unmarshal(buf[:n], &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.UUID != call.ID() {
+		t.Errorf("relayed UUID = %q, want %q", payload.Data.UUID, call.ID())
+	}
+	if payload.Data.Notifier.Diagnostic["truncated"] == nil {
+		t.Errorf("Diagnostic = %v, want truncated", payload.Data.Notifier.Diagnostic)
+	}
+}
+
+func TestWithRelay_unavailable(t *testing.T) {
+	cl := New("xxxxxxxxxxxxxxxx", WithRelay("unix", filepath.Join(t.TempDir(), "missing.sock")))
+	if _, err := cl.Error(errTest).Do(context.Background()); err == nil {
+		t.Error("Do() error = nil, want dial error")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// roundTripReportTimeout is the timeout of reporting the failed request.
+const roundTripReportTimeout = 10 * time.Second
+
+// RoundTripper is an http.RoundTripper which records the outbound requests as
+// network telemetry events, and optionally reports their failures.
+//
+// The event is recorded to the Telemetry carried by the request context if any,
+// otherwise to the Client telemetry. The URL is scrubbed of the password and
+// the sensitive query parameters.
+type RoundTripper struct {
+	// Base is the underlying RoundTripper. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+	// Client is the client which records and reports the requests.
+	Client Client
+	// ReportHosts is the list of hosts whose transport errors and 5xx responses
+	// are reported as warning items. A leading "*." matches any subdomain, and
+	// "*" matches any host.
+	ReportHosts []string
+}
+
+// NewRoundTripper returns a new RoundTripper which records the requests sent
+// by base to the cl telemetry, and reports the failures of hosts.
+func NewRoundTripper(cl Client, base http.RoundTripper, hosts ...string) *RoundTripper {
+	return &RoundTripper{
+		Base:        base,
+		Client:      cl,
+		ReportHosts: hosts,
+	}
+}
+
+// ResponseError is the error reported for a 5xx response of the outbound request.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+// Error implements the error interface.
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+}
+
+type roundTripKey struct{}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := rt.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	if ctx.Value(roundTripKey{}) != nil { // the request posted to rollbar
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	end := time.Now()
+
+	u := scrubURL(req.URL)
+	var statusCode int
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	t := TelemetryFromContext(ctx)
+	if t == nil && rt.Client != nil {
+		t = rt.Client.Telemetry()
+	}
+	t.Network(req.Method, u, statusCode, start, end)
+
+	switch {
+	case rt.Client == nil, !rt.reportHost(req.URL.Hostname()):
+	case err != nil:
+		rt.report(err, req.Method, u)
+	case resp.StatusCode >= 500:
+		rt.report(&ResponseError{
+			Method:     req.Method,
+			URL:        u,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}, req.Method, u)
+	}
+
+	return resp, err
+}
+
+// report reports the failure as a warning item in background.
+func (rt *RoundTripper) report(err error, method, u string) {
+	call := rt.Client.Warn(err).Custom(map[string]interface{}{
+		"method": method,
+		"url":    u,
+	})
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), roundTripReportTimeout)
+		defer cancel()
+		call.Do(ctx)
+	}()
+}
+
+func (rt *RoundTripper) reportHost(host string) bool {
+	for _, h := range rt.ReportHosts {
+		switch {
+		case h == "*", h == host:
+			return true
+		case strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// scrubURL returns the URL without the password and the sensitive query parameters.
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	if u.User != nil {
+		scrubbed.User = url.User(u.User.Username())
+	}
+	if u.RawQuery != "" {
+		scrubbed.RawQuery = url.Values(filterParams(reFilterFields, u.Query())).Encode()
+	}
+	return scrubbed.String()
+}
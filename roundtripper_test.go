@@ -0,0 +1,67 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+func TestRoundTripper(t *testing.T) {
+	payloads := make(chan *api.Payload, 1)
+	rollbarServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p api.Payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		payloads <- &p
+		w.Write([]byte(`{"err":0}`))
+	}))
+	defer rollbarServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	cl := New("xxxxxxxxxxxxxxxx", WithEndpoint(rollbarServer.URL))
+	hc := &http.Client{Transport: NewRoundTripper(cl, nil, serverURL.Hostname())}
+	resp, err := hc.Get(server.URL + "/path?q=1&token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	events := cl.Telemetry().Events()
+	if len(events) != 1 {
+		t.Fatalf("len(Telemetry().Events()) = %d, want 1", len(events))
+	}
+	body := events[0].Body
+	if want := server.URL + "/path?q=1&token=xxxxxxxxxxxx+%28redacted%29"; body.URL != want {
+		t.Errorf("event URL = %q, want %q", body.URL, want)
+	}
+	if body.Method != http.MethodGet || body.StatusCode != "502" || events[0].Level != string(ErrorLevel) {
+		t.Errorf("event = %+v", events[0])
+	}
+
+	select {
+	case p := <-payloads:
+		if p.Data.Level != string(WarnLevel) {
+			t.Errorf("reported level = %q, want %q", p.Data.Level, WarnLevel)
+		}
+		if got, want := p.Data.Body.Trace.Exception.Class, "rollbar.ResponseError"; got != want {
+			t.Errorf("reported class = %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure was not reported")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 
@@ -17,38 +18,97 @@ import (
 // Stack represents a api.Frame slice.
 type Stack []*api.Frame
 
+// DefaultStackDepth is the default maximum number of frames of the stack.
+const DefaultStackDepth = 64
+
+// pkgPath is the import path of this package, whose frames are skipped from
+// the stack of the reported items.
+var pkgPath = reflect.TypeOf(client{}).PkgPath()
+
 // CreateStack creates the Stack data except before skip callers.
+// The skip 0 is the frame of CreateStack itself.
 func CreateStack(skip int) Stack {
-	var stack Stack
+	pcs := make([]uintptr, DefaultStackDepth)
+	n := runtime.Callers(skip+1, pcs)
+	return newStack(runtime.CallersFrames(pcs[:n]), n, DefaultStackDepth, 0, false)
+}
 
-	for i := skip; ; i++ {
-		pc, file, line, ok := runtime.Caller(i)
-		if !ok {
-			break
-		}
-		stack = append(stack, &api.Frame{
-			Filename: file,
-			Method:   funcName(pc),
-			Lineno:   line,
-		})
+// CreateStackFromCaller creates the Stack data from callers, such as the
+// return program counters reported by runtime.Callers.
+func CreateStackFromCaller(callers []uintptr) Stack {
+	return newStack(runtime.CallersFrames(callers), len(callers), len(callers), 0, false)
+}
+
+// stackMargin is the number of frames captured more than the stack depth, for
+// the frames of this package.
+const stackMargin = 16
+
+// callers captures the return program counters of the caller of the client.
+// It records only the raw PCs, which are resolved to the Stack data by
+// callerStack when the item is sent.
+func callers(skip, depth int) []uintptr {
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	var buf [DefaultStackDepth + stackMargin]uintptr
+	pcs := buf[:]
+	if n := depth + skip + stackMargin; n > len(buf) {
+		pcs = make([]uintptr, n)
 	}
+	n := runtime.Callers(2, pcs)
+	return append([]uintptr(nil), pcs[:n]...)
+}
 
-	return stack
+// callerStack resolves the pcs captured by callers to the Stack data of at
+// most depth frames. The frames of this package are skipped regardless of the
+// calling path, then skip more frames.
+func callerStack(pcs []uintptr, skip, depth int) Stack {
+	if depth <= 0 {
+		depth = DefaultStackDepth
+	}
+	return newStack(runtime.CallersFrames(pcs), len(pcs), depth, skip, true)
 }
 
-// CreateStackFromCaller creates the Stack data from callers.
-func CreateStackFromCaller(callers []uintptr) Stack {
-	stack := make(Stack, 0, len(callers))
-
-	for _, caller := range callers {
-		if fn := runtime.FuncForPC(caller); fn != nil {
-			file, line := fn.FileLine(caller)
-			stack = append(stack, &api.Frame{
-				Filename: file,
-				Method:   funcNameFromFunc(fn),
-				Lineno:   line,
-			})
+// newStack creates the Stack data of at most depth frames from n program
+// counters. If skipPkg is true, the leading frames of this package are skipped,
+// then skip more frames.
+//
+// The inlined functions are expanded by frames.
+func newStack(frames *runtime.Frames, n, depth, skip int, skipPkg bool) Stack {
+	if n > depth {
+		n = depth
+	}
+	var buf []api.Frame // allocates the frames at once
+	stack := make(Stack, 0, n)
+
+	for more := true; more && len(stack) < depth; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		if frame.Function == "" && frame.File == "" {
+			continue
+		}
+		if skipPkg && funcPackage(frame.Function) == pkgPath {
+			continue
+		}
+		skipPkg = false
+		if skip > 0 {
+			skip--
+			continue
 		}
+
+		function := frame.Function
+		if function == "" {
+			function = "???"
+		}
+		if len(buf) == cap(buf) { // more inlined frames than program counters
+			buf = make([]api.Frame, 0, n)
+		}
+		buf = buf[:len(buf)+1]
+		f := &buf[len(buf)-1]
+		f.Filename, f.InApp = normalizeFilename(function, frame.File)
+		f.Method = shortFuncName(function)
+		f.Lineno = frame.Line
+		stack = append(stack, f)
 	}
 
 	return stack
@@ -66,15 +126,13 @@ func (s Stack) Fingerprint() string {
 	return fmt.Sprintf("%x", h.Sum32())
 }
 
-func funcNameFromFunc(fn *runtime.Func) string {
-	if fn == nil {
-		return "???"
+// shortFuncName trims the package path from the fully qualified function name.
+// The generic type parameters, which may contain a path, are kept as is.
+func shortFuncName(name string) string {
+	pkg := name
+	if i := strings.Index(pkg, "["); i >= 0 {
+		pkg = pkg[:i]
 	}
-	name := fn.Name()
-	end := strings.LastIndex(name, string(filepath.Separator))
+	end := strings.LastIndex(pkg, string(filepath.Separator))
 	return name[end+1:]
 }
-
-func funcName(pc uintptr) string {
-	return funcNameFromFunc(runtime.FuncForPC(pc))
-}
@@ -0,0 +1,103 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// inlinedCallers is small enough to be inlined into its caller.
+func inlinedCallers() []uintptr {
+	pcs := make([]uintptr, DefaultStackDepth)
+	n := runtime.Callers(1, pcs)
+	return pcs[:n]
+}
+
+func TestCreateStackFromCaller_inlined(t *testing.T) {
+	stack := CreateStackFromCaller(inlinedCallers())
+	if len(stack) < 2 {
+		t.Fatalf("len(CreateStackFromCaller()) = %d, want >= 2", len(stack))
+	}
+	if got, want := stack[0].Method, "go-rollbar.inlinedCallers"; got != want {
+		t.Errorf("stack[0].Method = %q, want %q", got, want)
+	}
+	if got, want := stack[1].Method, "go-rollbar.TestCreateStackFromCaller_inlined"; got != want {
+		t.Errorf("stack[1].Method = %q, want %q", got, want)
+	}
+}
+
+func Test_callerStack(t *testing.T) {
+	tests := []struct {
+		name       string
+		skip       int
+		depth      int
+		wantLen    int
+		wantMethod string
+	}{
+		{
+			name:       "default",
+			wantLen:    2,
+			wantMethod: "testing.tRunner",
+		},
+		{
+			name:       "skip",
+			skip:       1,
+			wantLen:    1,
+			wantMethod: "runtime.goexit",
+		},
+		{
+			name:       "depth",
+			depth:      1,
+			wantLen:    1,
+			wantMethod: "testing.tRunner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the frames of the test functions are in this package, so skipped
+			stack := callerStack(callers(tt.skip, tt.depth), tt.skip, tt.depth)
+			if len(stack) != tt.wantLen {
+				t.Fatalf("len(callerStack(%d, %d)) = %d, want %d", tt.skip, tt.depth, len(stack), tt.wantLen)
+			}
+			if got := stack[0].Method; got != tt.wantMethod {
+				t.Errorf("callerStack(%d, %d)[0].Method = %q, want %q", tt.skip, tt.depth, got, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func BenchmarkCreateStack(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CreateStack(0)
+	}
+}
+
+// BenchmarkClient_Error measures the cost of the Error call in the request
+// goroutine, which captures only the program counters.
+func BenchmarkClient_Error(b *testing.B) {
+	cl := New("xxxxxxxxxxxxxxxx")
+	err := errors.New("benchmark error")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cl.Error(err)
+	}
+}
+
+// BenchmarkClient_Error_resolve measures the cost of the Error call with the
+// stack resolved eagerly, as the delivery does.
+func BenchmarkClient_Error_resolve(b *testing.B) {
+	cl := New("xxxxxxxxxxxxxxxx")
+	err := errors.New("benchmark error")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		call := cl.Error(err).(*ErrorCall)
+		callerStack(call.pcs, 0, 0)
+	}
+}
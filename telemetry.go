@@ -0,0 +1,197 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// DefaultTelemetrySize is the default number of telemetry events kept by the client.
+const DefaultTelemetrySize = 50
+
+const telemetrySource = "server"
+
+// Telemetry is a bounded ring buffer of telemetry events, also known as breadcrumbs.
+// The most recent events are attached to every reported item.
+//
+// It is safe for concurrent use. All methods of a nil *Telemetry are no-op.
+type Telemetry struct {
+	mu     sync.Mutex
+	events []*api.Telemetry
+	next   int
+	full   bool
+}
+
+// NewTelemetry creates a new Telemetry which keeps the last size events.
+func NewTelemetry(size int) *Telemetry {
+	if size < 1 {
+		size = 1
+	}
+	return &Telemetry{
+		events: make([]*api.Telemetry, size),
+	}
+}
+
+// Record records the telemetry event. The zero timestamp, source and level of
+// the event are set to now, "server" and "info".
+func (t *Telemetry) Record(e *api.Telemetry) {
+	if t == nil || e == nil {
+		return
+	}
+	if e.TimestampMs == 0 {
+		e.TimestampMs = timestampMs(time.Now())
+	}
+	if e.Source == "" {
+		e.Source = telemetrySource
+	}
+	if e.Level == "" {
+		e.Level = string(InfoLevel)
+	}
+
+	t.mu.Lock()
+	t.events[t.next] = e
+	t.next = (t.next + 1) % len(t.events)
+	if t.next == 0 {
+		t.full = true
+	}
+	t.mu.Unlock()
+}
+
+// Log records the log message event.
+func (t *Telemetry) Log(level Level, message string) {
+	t.Record(&api.Telemetry{
+		Level: string(level),
+		Type:  "log",
+		Body: api.TelemetryBody{
+			Message: message,
+		},
+	})
+}
+
+// Network records the network request event. The level is chosen by the status code.
+func (t *Telemetry) Network(method, url string, statusCode int, start, end time.Time) {
+	level := InfoLevel
+	switch {
+	case statusCode == 0, statusCode >= 500:
+		level = ErrorLevel
+	case statusCode >= 400:
+		level = WarnLevel
+	}
+
+	var code string
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+
+	t.Record(&api.Telemetry{
+		Level:       string(level),
+		Type:        "network",
+		TimestampMs: timestampMs(start),
+		Body: api.TelemetryBody{
+			Subtype:          "http",
+			Method:           method,
+			URL:              url,
+			StatusCode:       code,
+			StartTimestampMs: timestampMs(start),
+			EndTimestampMs:   timestampMs(end),
+		},
+	})
+}
+
+// Navigation records the navigation event, such as a state transition of the application.
+func (t *Telemetry) Navigation(from, to string) {
+	t.Record(&api.Telemetry{
+		Type: "navigation",
+		Body: api.TelemetryBody{
+			From: from,
+			To:   to,
+		},
+	})
+}
+
+// Error records the error event.
+func (t *Telemetry) Error(err error) {
+	message := "<nil>"
+	if err != nil {
+		message = err.Error()
+	}
+	t.Record(&api.Telemetry{
+		Level: string(ErrorLevel),
+		Type:  "error",
+		Body: api.TelemetryBody{
+			Message: message,
+		},
+	})
+}
+
+// Manual records the manual event.
+func (t *Telemetry) Manual(level Level, message string) {
+	t.Record(&api.Telemetry{
+		Level: string(level),
+		Type:  "manual",
+		Body: api.TelemetryBody{
+			Message: message,
+		},
+	})
+}
+
+// Events returns the recorded events, oldest first.
+func (t *Telemetry) Events() []*api.Telemetry {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var events []*api.Telemetry
+	if t.full {
+		events = append(events, t.events[t.next:]...)
+	}
+	return append(events, t.events[:t.next]...)
+}
+
+type telemetryKey struct{}
+
+// NewTelemetryContext returns a new context which carries the Telemetry, such as
+// the request-scoped events. Its events are attached to the items reported
+// with the context, along with the client events.
+func NewTelemetryContext(ctx context.Context, t *Telemetry) context.Context {
+	return context.WithValue(ctx, telemetryKey{}, t)
+}
+
+// TelemetryFromContext returns the Telemetry carried by ctx, or nil.
+func TelemetryFromContext(ctx context.Context) *Telemetry {
+	t, _ := ctx.Value(telemetryKey{}).(*Telemetry)
+	return t
+}
+
+// telemetryEvents returns the last client and ctx events, oldest first.
+func (c *httpClient) telemetryEvents(ctx context.Context) []*api.Telemetry {
+	events := c.telemetry.Events()
+	t := TelemetryFromContext(ctx)
+	if t == nil || t == c.telemetry {
+		return events
+	}
+
+	events = append(events, t.Events()...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].TimestampMs < events[j].TimestampMs
+	})
+	return events
+}
+
+func timestampMs(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func TestTelemetry_Events(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		n    int
+		want []string
+	}{
+		{
+			name: "empty",
+			size: 3,
+			n:    0,
+			want: nil,
+		},
+		{
+			name: "not full",
+			size: 3,
+			n:    2,
+			want: []string{"0", "1"},
+		},
+		{
+			name: "wrap around",
+			size: 3,
+			n:    5,
+			want: []string{"2", "3", "4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := NewTelemetry(tt.size)
+			for i := 0; i < tt.n; i++ {
+				tm.Log(InfoLevel, strconv.Itoa(i))
+			}
+
+			var got []string
+			for _, e := range tm.Events() {
+				got = append(got, e.Body.Message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Telemetry.Events() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTelemetry_nil(t *testing.T) {
+	var tm *Telemetry
+	tm.Log(InfoLevel, "message")
+	if got := tm.Events(); got != nil {
+		t.Errorf("(*Telemetry)(nil).Events() = %v, want nil", got)
+	}
+}
+
+func Test_httpClient_telemetryEvents(t *testing.T) {
+	c := &httpClient{telemetry: NewTelemetry(DefaultTelemetrySize)}
+	c.telemetry.Record(&api.Telemetry{Type: "log", TimestampMs: 1})
+	c.telemetry.Record(&api.Telemetry{Type: "log", TimestampMs: 3})
+
+	reqTelemetry := NewTelemetry(DefaultTelemetrySize)
+	reqTelemetry.Record(&api.Telemetry{Type: "network", TimestampMs: 2})
+	ctx := NewTelemetryContext(context.Background(), reqTelemetry)
+
+	var got []int64
+	for _, e := range c.telemetryEvents(ctx) {
+		got = append(got, e.TimestampMs)
+	}
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("httpClient.telemetryEvents() timestamps = %v, want %v", got, want)
+	}
+}
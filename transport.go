@@ -0,0 +1,130 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+// Transport sends the payload to rollbar or an alternative sink.
+//
+// The payload is validated before it is sent. Send must be safe for the
+// concurrent use, and must not modify the payload after it returns.
+type Transport interface {
+	Send(ctx context.Context, payload *api.Payload) (*api.Response, error)
+}
+
+// NewHTTPTransport creates the Transport which posts the payload to the rollbar API.
+// It is the default transport of the client.
+//
+// The options of the HTTP client, endpoint, logger, debug mode, payload size and
+// compression are applied, and the others are ignored. The access token is taken
+// from the payload.
+func NewHTTPTransport(options ...Option) Transport {
+	cl := defaultHTTPClient
+	for _, o := range options {
+		o(&cl)
+	}
+	if _, ok := cl.logger.(nilLogger); cl.debug && ok {
+		cl.logger = traceLogger{os.Stderr}
+	}
+	cl.transport = nil // the transport of the options
+	return &cl
+}
+
+// Send implements Transport. The error response is returned as *APIError.
+func (c *httpClient) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	req, err := c.newRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	var res api.Response
+	err = c.Do(ctx, req, &res)
+	return &res, err
+}
+
+// send sends the payload with the transport of the client, or posts it to the rollbar API.
+func (c *httpClient) send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	if c.transport == nil {
+		return c.Send(ctx, payload)
+	}
+	return c.transport.Send(ctx, payload)
+}
+
+// localResponse returns the successful response of the payload not sent to the rollbar API.
+func localResponse(payload *api.Payload) *api.Response {
+	res := new(api.Response)
+	if payload.Data != nil {
+		res.Result.UUID = payload.Data.UUID
+	}
+	return res
+}
+
+type writerTransport struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriterTransport creates the Transport which writes the payload to w as a
+// line of JSON. The writes are serialized.
+func NewWriterTransport(w io.Writer) Transport {
+	return &writerTransport{w: w}
+}
+
+// Send implements Transport.
+func (t *writerTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, err := payload.AppendJSON(t.buf[:0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode payload")
+	}
+	t.buf = append(b, '\n')
+	if _, err := t.w.Write(t.buf); err != nil {
+		return nil, errors.Wrap(err, "failed to write payload")
+	}
+	if cap(t.buf) > maxPooledBufferSize {
+		t.buf = nil
+	}
+	return localResponse(payload), nil
+}
+
+type chanTransport chan<- *api.Payload
+
+// NewChanTransport creates the Transport which sends the payload to ch. Send
+// blocks until ch receives the payload or ctx is done.
+func NewChanTransport(ch chan<- *api.Payload) Transport {
+	return chanTransport(ch)
+}
+
+// Send implements Transport.
+func (t chanTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	select {
+	case t <- payload:
+		return localResponse(payload), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type nopTransport struct{}
+
+// NewNopTransport creates the Transport which discards the payload.
+func NewNopTransport() Transport {
+	return nopTransport{}
+}
+
+// Send implements Transport.
+func (nopTransport) Send(ctx context.Context, payload *api.Payload) (*api.Response, error) {
+	return localResponse(payload), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func TestWithTransport(t *testing.T) {
+	var buf bytes.Buffer
+	cl := New("xxxxxxxxxxxxxxxx", WithTransport(NewWriterTransport(&buf)), WithTelemetrySize(0))
+	var ids []string
+	for i := 0; i < 2; i++ {
+		call := cl.Error(errTest)
+		res, err := call.Do(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Result.UUID != call.ID() {
+			t.Errorf("Result.UUID = %q, want %q", res.Result.UUID, call.ID())
+		}
+		ids = append(ids, call.ID())
+	}
+
+	sc := bufio.NewScanner(&buf)
+	for i := 0; sc.Scan(); i++ {
+		var payload api.Payload
+		if err := json.Unmarshal(sc.Bytes(), &payload); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if i >= len(ids) {
+			t.Fatalf("line %d: unexpected payload", i)
+		}
+		if payload.Data.UUID != ids[i] || payload.Data.Title != errTest.Error() {
+			t.Errorf("line %d: UUID, Title = %q, %q, want %q, %q", i, payload.Data.UUID, payload.Data.Title, ids[i], errTest.Error())
+		}
+		if payload.AccessToken != "xxxxxxxxxxxxxxxx" {
+			t.Errorf("line %d: AccessToken = %q", i, payload.AccessToken)
+		}
+	}
+}
+
+func TestNewChanTransport(t *testing.T) {
+	ch := make(chan *api.Payload, 1)
+	cl := New("xxxxxxxxxxxxxxxx", WithTransport(NewChanTransport(ch)))
+
+	call := cl.Error(errTest)
+	if _, err := call.Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if payload := <-ch; payload.Data.UUID != call.ID() {
+		t.Errorf("UUID = %q, want %q", payload.Data.UUID, call.ID())
+	}
+
+	// the channel is full
+	ch <- nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cl.Error(errTest).Do(ctx); errors.Cause(err) != context.Canceled {
+		t.Errorf("Do() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNewNopTransport(t *testing.T) {
+	cl := New("", WithTransport(NewNopTransport()))
+	call := cl.Error(errTest)
+	res, err := call.Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Result.UUID != call.ID() {
+		t.Errorf("Result.UUID = %q, want %q", res.Result.UUID, call.ID())
+	}
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload api.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		if payload.AccessToken == "invalid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"err":1,"message":"invalid access token"}`))
+			return
+		}
+		w.Write([]byte(`{"err":0,"result":{"id":null,"uuid":"` + payload.Data.UUID + `"}}`))
+	}))
+	defer server.Close()
+
+	tr := NewHTTPTransport(WithEndpoint(server.URL))
+	payload := &api.Payload{
+		AccessToken: "xxxxxxxxxxxxxxxx",
+		Data:        &api.Data{Environment: "test", UUID: NewUUID()},
+	}
+	res, err := tr.Send(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Result.UUID != payload.Data.UUID {
+		t.Errorf("Result.UUID = %q, want %q", res.Result.UUID, payload.Data.UUID)
+	}
+
+	payload.AccessToken = "invalid"
+	if _, err := tr.Send(context.Background(), payload); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Send() error = %v, want %v", err, ErrUnauthorized)
+	}
+
+	payload.AccessToken = ""
+	if _, err := tr.Send(context.Background(), payload); err == nil {
+		t.Error("Send() error = nil, want empty token error")
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// DefaultMaxPayloadSize is the default byte budget of the encoded payload.
+// Rollbar rejects the larger payloads.
+const DefaultMaxPayloadSize = 512 << 10
+
+// ErrPayloadTooLarge is returned when the payload exceeds the byte budget even after the truncation.
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+const (
+	// truncateStringLength is the length of the long strings truncated to.
+	truncateStringLength = 1024
+	// truncateFramesHead and truncateFramesTail are the number of frames kept
+	// at the head and the tail of the long stacks.
+	truncateFramesHead = 10
+	truncateFramesTail = 10
+)
+
+// truncator truncates a part of the payload data. It reports whether the data is changed.
+type truncator struct {
+	name     string
+	truncate func(d *api.Data) bool
+}
+
+// truncators is the list of the truncation strategies, applied in order until
+// the payload fits the budget. The custom data is reduced at last, see marshalPayload.
+var truncators = []truncator{
+	{"strings", truncateStrings},
+	{"frames", truncateFrames},
+}
+
+// marshalPayload appends the encoded payload to dst within max bytes, and returns
+// the extended buffer. If the encoded payload is
+// larger, it is truncated in place, and the applied strategies are recorded to
+// the notifier diagnostic data as "truncated". A max of zero or less disables
+// the truncation.
+func marshalPayload(dst []byte, payload *api.Payload, max int) ([]byte, error) {
+	start := len(dst)
+	b, err := payload.AppendJSON(dst)
+	if err != nil || max <= 0 || len(b)-start <= max || payload.Data == nil {
+		return b, err
+	}
+
+	size := len(b) - start
+	var truncated []string
+	marshal := func(name string) (bool, error) {
+		if len(truncated) == 0 || truncated[len(truncated)-1] != name {
+			truncated = append(truncated, name)
+		}
+		markTruncated(payload.Data, truncated, size)
+		b, err = payload.AppendJSON(b[:start])
+		return err == nil && len(b)-start <= max, err
+	}
+
+	for _, t := range truncators {
+		if !t.truncate(payload.Data) {
+			continue
+		}
+		if ok, err := marshal(t.name); ok || err != nil {
+			return b, err
+		}
+	}
+
+	// drop the custom data, the largest key first
+	custom := payload.Data.Custom
+	keys := customKeysBySize(custom)
+	if len(keys) > 0 {
+		payload.Data.Custom = make(map[string]interface{}, len(custom))
+		for k, v := range custom {
+			payload.Data.Custom[k] = v // not to modify the map of the caller
+		}
+	}
+	for _, k := range keys {
+		delete(payload.Data.Custom, k)
+		if ok, err := marshal("custom"); ok || err != nil {
+			return b, err
+		}
+	}
+
+	return b[:start], errors.Wrapf(ErrPayloadTooLarge, "%d bytes exceeds %d bytes after truncation", len(b)-start, max)
+}
+
+// markTruncated records the applied strategies and the original size of the payload.
+func markTruncated(d *api.Data, truncated []string, size int) {
+	setDiagnostic(d, "truncated", truncated)
+	setDiagnostic(d, "original_size", size)
+}
+
+// setDiagnostic sets the value to the diagnostic data of the notifier.
+func setDiagnostic(d *api.Data, key string, value interface{}) {
+	if d.Notifier == nil {
+		d.Notifier = &api.Notifier{Name: Name, Version: Version}
+	}
+	if d.Notifier.Diagnostic == nil {
+		d.Notifier.Diagnostic = make(map[string]interface{})
+	}
+	d.Notifier.Diagnostic[key] = value
+}
+
+// truncateStrings truncates the request body and the long strings of the
+// exceptions, messages, crash reports, telemetry events and custom data.
+func truncateStrings(d *api.Data) bool {
+	changed := false
+	trunc := func(s *string) {
+		if t, ok := truncateString(*s, truncateStringLength); ok {
+			*s, changed = t, true
+		}
+	}
+
+	trunc(&d.Title)
+	if r := d.Request; r != nil {
+		trunc(&r.Body)
+		trunc(&r.QueryString)
+	}
+	if b := d.Body; b != nil {
+		traces := b.TraceChain
+		if b.Trace != nil {
+			traces = append([]*api.Trace{b.Trace}, traces...)
+		}
+		for _, t := range traces {
+			if e := t.Exception; e != nil {
+				trunc(&e.Message)
+				trunc(&e.Description)
+			}
+		}
+		if b.Message != nil {
+			trunc(&b.Message.Body)
+		}
+		if b.CrashReport != nil {
+			trunc(&b.CrashReport.Raw)
+		}
+		for i, e := range b.Telemetry {
+			if _, ok := truncateString(e.Body.Message, truncateStringLength); !ok {
+				continue
+			}
+			c := *e // the event is shared with the telemetry buffer
+			trunc(&c.Body.Message)
+			b.Telemetry[i] = &c
+		}
+	}
+	if v, ok := truncateValue(d.Custom); ok {
+		d.Custom, changed = v.(map[string]interface{}), true
+	}
+	return changed
+}
+
+// truncateValue truncates the long strings in the custom data value v. The maps
+// and slices are copied on change, not to modify the values of the caller.
+// It reports whether v is changed.
+func truncateValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		return truncateString(v, truncateStringLength)
+	case map[string]interface{}:
+		var m map[string]interface{}
+		for k, e := range v {
+			t, ok := truncateValue(e)
+			if !ok {
+				continue
+			}
+			if m == nil {
+				m = make(map[string]interface{}, len(v))
+				for k, e := range v {
+					m[k] = e
+				}
+			}
+			m[k] = t
+		}
+		if m == nil {
+			return v, false
+		}
+		return m, true
+	case []interface{}:
+		var a []interface{}
+		for i, e := range v {
+			t, ok := truncateValue(e)
+			if !ok {
+				continue
+			}
+			if a == nil {
+				a = append([]interface{}(nil), v...)
+			}
+			a[i] = t
+		}
+		if a == nil {
+			return v, false
+		}
+		return a, true
+	default:
+		return v, false
+	}
+}
+
+// truncateString truncates s to n bytes at the rune boundary. It reports whether s is truncated.
+func truncateString(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+	const ellipsis = "..."
+	n -= len(ellipsis)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + ellipsis, true
+}
+
+// truncateFrames drops the middle frames of the long stacks, keeping the head and the tail.
+func truncateFrames(d *api.Data) bool {
+	if d.Body == nil {
+		return false
+	}
+	traces := d.Body.TraceChain
+	if d.Body.Trace != nil {
+		traces = append([]*api.Trace{d.Body.Trace}, traces...)
+	}
+
+	changed := false
+	for _, t := range traces {
+		if len(t.Frames) <= truncateFramesHead+truncateFramesTail {
+			continue
+		}
+		frames := make([]*api.Frame, 0, truncateFramesHead+truncateFramesTail)
+		frames = append(frames, t.Frames[:truncateFramesHead]...)
+		frames = append(frames, t.Frames[len(t.Frames)-truncateFramesTail:]...)
+		t.Frames = frames
+		changed = true
+	}
+	return changed
+}
+
+// customKeysBySize returns the keys of custom in the descending order of the encoded value size.
+func customKeysBySize(custom map[string]interface{}) []string {
+	sizes := make(map[string]int, len(custom))
+	keys := make([]string, 0, len(custom))
+	for k, v := range custom {
+		b, _ := json.Marshal(v)
+		sizes[k] = len(k) + len(b)
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if sizes[keys[i]] != sizes[keys[j]] {
+			return sizes[keys[i]] > sizes[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
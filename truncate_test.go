@@ -0,0 +1,147 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+func testLargePayload() *api.Payload {
+	frames := make([]*api.Frame, 100)
+	for i := range frames {
+		frames[i] = &api.Frame{Filename: strings.Repeat("f", 200), Lineno: i}
+	}
+	return &api.Payload{
+		AccessToken: "xxxxxxxxxxxxxxxx",
+		Data: &api.Data{
+			Request: &api.Request{Body: strings.Repeat("b", 10000)},
+			Body: &api.Body{
+				Trace: &api.Trace{
+					Frames:    frames,
+					Exception: &api.Exception{Class: "error", Message: strings.Repeat("m", 10000)},
+				},
+			},
+			Custom: map[string]interface{}{
+				"small": "s",
+				"large": []interface{}{strings.Repeat("c", 2000), 1},
+				"count": map[string]int{"a": 1},
+			},
+			Notifier: &api.Notifier{Name: Name, Version: Version},
+		},
+	}
+}
+
+func Test_marshalPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		max           int
+		wantTruncated []string
+		wantFrames    int
+		wantCustom    []string
+		wantErr       bool
+	}{
+		{
+			name:       "fits",
+			max:        1 << 20,
+			wantFrames: 100,
+			wantCustom: []string{"count", "large", "small"},
+		},
+		{
+			name:          "strings",
+			max:           30000,
+			wantTruncated: []string{"strings"},
+			wantFrames:    100,
+			wantCustom:    []string{"count", "large", "small"},
+		},
+		{
+			name:          "frames",
+			max:           8100,
+			wantTruncated: []string{"strings", "frames"},
+			wantFrames:    20,
+			wantCustom:    []string{"count", "large", "small"},
+		},
+		{
+			name:          "custom",
+			max:           7100,
+			wantTruncated: []string{"strings", "frames", "custom"},
+			wantFrames:    20,
+			wantCustom:    []string{"count", "small"},
+		},
+		{
+			name:    "too large",
+			max:     100,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := testLargePayload()
+			custom := payload.Data.Custom
+			b, err := marshalPayload(nil, payload, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("marshalPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(custom) != 3 {
+				t.Errorf("modified the custom data of the caller: %v", custom)
+			}
+			if tt.wantErr {
+				if errors.Cause(err) != ErrPayloadTooLarge {
+					t.Errorf("marshalPayload() error = %v, want ErrPayloadTooLarge", err)
+				}
+				return
+			}
+			if len(b) > tt.max {
+				t.Errorf("len(marshalPayload()) = %d, want <= %d", len(b), tt.max)
+			}
+
+			var got struct {
+				Data struct {
+					Body struct {
+						Trace struct {
+							Frames []json.RawMessage `json:"frames"`
+						} `json:"trace"`
+					} `json:"body"`
+					Custom   map[string]interface{} `json:"custom"`
+					Notifier struct {
+						Diagnostic struct {
+							Truncated []string `json:"truncated"`
+						} `json:"diagnostic"`
+					} `json:"notifier"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got := got.Data.Notifier.Diagnostic.Truncated; !reflect.DeepEqual(got, tt.wantTruncated) {
+				t.Errorf("truncated = %v, want %v", got, tt.wantTruncated)
+			}
+			if got := len(got.Data.Body.Trace.Frames); got != tt.wantFrames {
+				t.Errorf("len(frames) = %d, want %d", got, tt.wantFrames)
+			}
+			var keys []string
+			for k := range got.Data.Custom {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.wantCustom) {
+				t.Errorf("custom keys = %v, want %v", keys, tt.wantCustom)
+			}
+		})
+	}
+}
+
+func Test_truncateString(t *testing.T) {
+	got, ok := truncateString("ああああ", 8) // 3 bytes per rune
+	if !ok || got != "あ..." {
+		t.Errorf("truncateString() = %q, %v, want %q, true", got, ok, "あ...")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewUUID returns a random UUID version 4, such as "6ba7b810-9dad-41d1-80b4-00c04fd430c8",
+// which identifies the occurrence in the payload data.
+// It returns an empty string if the random source fails, and rollbar assigns one then.
+func NewUUID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return ""
+	}
+	u[6] = u[6]&0x0f | 0x40 // version 4
+	u[8] = u[8]&0x3f | 0x80 // variant RFC 4122
+
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+var errTest = errors.New("test")
+
+var reUUID4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewUUID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewUUID()
+		if !reUUID4.MatchString(id) {
+			t.Fatalf("NewUUID() = %q, want UUID4", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewUUID() = %q, duplicated", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestCall_ID(t *testing.T) {
+	var uuids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload api.Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		uuids = append(uuids, payload.Data.UUID)
+		w.Write([]byte(`{"err":0,"result":{"id":null,"uuid":"x"}}`))
+	}))
+	defer server.Close()
+
+	cl := New("xxxxxxxxxxxxxxxx", WithEndpoint(server.URL))
+
+	call := cl.Error(errTest)
+	id := call.ID()
+	if !reUUID4.MatchString(id) {
+		t.Fatalf("ID() = %q, want UUID4", id)
+	}
+	if other := cl.Error(errTest).ID(); other == id {
+		t.Errorf("ID() = %q of other call, want other than %q", other, id)
+	}
+	for i := 0; i < 2; i++ { // resend
+		if _, err := call.Do(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	given := "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+	call = cl.Error(errTest).UUID(given)
+	if got := call.ID(); got != given {
+		t.Errorf("ID() = %q, want %q", got, given)
+	}
+	if _, err := call.Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{id, id, given}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("sent UUIDs = %v, want %v", uuids, want)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+)
+
+// The field limits of the rollbar API, in characters.
+const (
+	maxTitleLength       = 255
+	maxUUIDLength        = 36
+	maxPersonIDLength    = 40
+	maxPersonFieldLength = 255
+	maxEnvironmentLength = 255
+	maxCodeVersionLength = 40
+	maxFingerprintLength = 40
+)
+
+// ValidationError is returned by Call.Do when the payload has an invalid field
+// which can not be fixed.
+type ValidationError struct {
+	// Field is the path of the invalid field, such as "data.person.id".
+	Field string
+	// Value is the invalid value.
+	Value string
+	// Reason is the reason why the value is invalid.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("rollbar: invalid %s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// validatePayload validates the payload data against the rollbar API limits.
+//
+// It fixes the values where it can: the blank title is replaced with the
+// exception or the message of the body, the long title, environment, code version
+// and person username and email are truncated, and the long fingerprint is
+// replaced with its SHA1 hash, as rollbar does. The person with the invalid id
+// is dropped, and the reason is recorded in data.notifier.diagnostic, not to
+// lose the item for it. Otherwise it returns the ValidationError of the first
+// invalid field.
+func validatePayload(payload *api.Payload) error {
+	d := payload.Data
+	if d == nil {
+		return &ValidationError{Field: "data", Reason: "required"}
+	}
+
+	if d.Environment == "" {
+		return &ValidationError{Field: "data.environment", Reason: "required"}
+	}
+	d.Environment = truncateChars(d.Environment, maxEnvironmentLength)
+	if strings.TrimSpace(d.Title) == "" {
+		d.Title = defaultTitle(d)
+	}
+	d.Title = truncateChars(d.Title, maxTitleLength)
+	d.CodeVersion = truncateChars(d.CodeVersion, maxCodeVersionLength)
+	if d.Server != nil {
+		d.Server.CodeVersion = truncateChars(d.Server.CodeVersion, maxCodeVersionLength)
+	}
+	if utf8.RuneCountInString(d.Fingerprint) > maxFingerprintLength {
+		d.Fingerprint = fmt.Sprintf("%x", sha1.Sum([]byte(d.Fingerprint)))
+	}
+
+	if n := utf8.RuneCountInString(d.UUID); n > maxUUIDLength {
+		return &ValidationError{
+			Field:  "data.uuid",
+			Value:  d.UUID,
+			Reason: fmt.Sprintf("%d characters exceeds %d characters", n, maxUUIDLength),
+		}
+	}
+
+	if p := d.Person; p != nil {
+		switch n := utf8.RuneCountInString(p.ID); {
+		case n == 0:
+			dropPerson(d, "invalid id: required")
+		case n > maxPersonIDLength:
+			dropPerson(d, fmt.Sprintf("invalid id: %d characters exceeds %d characters", n, maxPersonIDLength))
+		default:
+			p.Username = truncateChars(p.Username, maxPersonFieldLength)
+			p.Email = truncateChars(p.Email, maxPersonFieldLength)
+		}
+	}
+
+	return nil
+}
+
+// defaultTitle returns the title of d without one, which is at least 1 character
+// in rollbar. It returns an empty string to omit the title if d has neither the
+// exception class nor the message, and rollbar determines it then.
+func defaultTitle(d *api.Data) string {
+	if d.Body == nil {
+		return ""
+	}
+	trace := d.Body.Trace
+	if trace == nil && len(d.Body.TraceChain) > 0 {
+		trace = d.Body.TraceChain[0]
+	}
+	if trace != nil && trace.Exception != nil {
+		if message := strings.TrimSpace(trace.Exception.Message); message != "" {
+			return message
+		}
+		return trace.Exception.Class
+	}
+	if d.Body.Message != nil {
+		return strings.TrimSpace(d.Body.Message.Body)
+	}
+	return ""
+}
+
+// dropPerson drops the person of d, recording the reason in the diagnostic data.
+func dropPerson(d *api.Data, reason string) {
+	d.Person = nil
+	setDiagnostic(d, "dropped_person", reason)
+}
+
+// truncateChars truncates s to n characters.
+func truncateChars(s string, n int) string {
+	if len(s) <= n { // fast path, the number of characters is not more than the bytes
+		return s
+	}
+	i := 0
+	for j := range s {
+		if i == n {
+			return s[:j]
+		}
+		i++
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 The go-rollbar Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rollbar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/zchee/go-rollbar/api/v1"
+	"golang.org/x/net/context"
+)
+
+func Test_validatePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    *api.Data
+		want    *api.Data
+		wantErr *ValidationError
+	}{
+		{
+			name: "valid",
+			data: &api.Data{Environment: "production", Title: "title", Person: &api.Person{ID: "1"}},
+			want: &api.Data{Environment: "production", Title: "title", Person: &api.Person{ID: "1"}},
+		},
+		{
+			name: "fixed",
+			data: &api.Data{
+				Environment: strings.Repeat("e", 300),
+				Title:       strings.Repeat("あ", 300),
+				CodeVersion: strings.Repeat("0123456789abcdef", 4),
+				Fingerprint: strings.Repeat("f", 41),
+				Person:      &api.Person{ID: "1", Email: strings.Repeat("m", 300)},
+			},
+			want: &api.Data{
+				Environment: strings.Repeat("e", 255),
+				Title:       strings.Repeat("あ", 255),
+				CodeVersion: strings.Repeat("0123456789abcdef", 3)[:40],
+				Fingerprint: "81d59f470bdcb2ac53852938ebd3fc740adf6299",
+				Person:      &api.Person{ID: "1", Email: strings.Repeat("m", 255)},
+			},
+		},
+		{
+			name: "blank title",
+			data: &api.Data{
+				Environment: "production",
+				Title:       " ",
+				Body:        &api.Body{Trace: &api.Trace{Exception: &api.Exception{Class: "*errors.errorString"}}},
+			},
+			want: &api.Data{
+				Environment: "production",
+				Title:       "*errors.errorString",
+				Body:        &api.Body{Trace: &api.Trace{Exception: &api.Exception{Class: "*errors.errorString"}}},
+			},
+		},
+		{
+			name: "empty title of message",
+			data: &api.Data{Environment: "production", Body: &api.Body{Message: &api.Message{Body: "started"}}},
+			want: &api.Data{Environment: "production", Title: "started", Body: &api.Body{Message: &api.Message{Body: "started"}}},
+		},
+		{
+			name:    "empty environment",
+			data:    &api.Data{},
+			wantErr: &ValidationError{Field: "data.environment", Reason: "required"},
+		},
+		{
+			name: "empty person id",
+			data: &api.Data{Environment: "production", Person: &api.Person{Username: "gopher"}},
+			want: &api.Data{
+				Environment: "production",
+				Notifier: &api.Notifier{
+					Name:       Name,
+					Version:    Version,
+					Diagnostic: map[string]interface{}{"dropped_person": "invalid id: required"},
+				},
+			},
+		},
+		{
+			name: "long person id",
+			data: &api.Data{Environment: "production", Person: &api.Person{ID: strings.Repeat("1", 41)}},
+			want: &api.Data{
+				Environment: "production",
+				Notifier: &api.Notifier{
+					Name:       Name,
+					Version:    Version,
+					Diagnostic: map[string]interface{}{"dropped_person": "invalid id: 41 characters exceeds 40 characters"},
+				},
+			},
+		},
+		{
+			name: "long uuid",
+			data: &api.Data{Environment: "production", UUID: strings.Repeat("u", 37)},
+			wantErr: &ValidationError{
+				Field:  "data.uuid",
+				Value:  strings.Repeat("u", 37),
+				Reason: "37 characters exceeds 36 characters",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePayload(&api.Payload{Data: tt.data})
+			if tt.wantErr != nil {
+				if !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("validatePayload() error = %#v, want %#v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validatePayload() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("validatePayload() data = %+v, want %+v", tt.data, tt.want)
+			}
+		})
+	}
+}
+
+func TestCall_Person_dropped(t *testing.T) {
+	ch := make(chan *api.Payload, 1)
+	cl := New("xxxxxxxxxxxxxxxx", WithTransport(NewChanTransport(ch)))
+	if _, err := cl.Error(errTest).Person("", "gopher", "gopher@example.com").Do(context.Background()); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	payload := <-ch
+	if payload.Data.Person != nil {
+		t.Errorf("Person = %+v, want nil", payload.Data.Person)
+	}
+	if got, want := payload.Data.Notifier.Diagnostic["dropped_person"], "invalid id: required"; got != want {
+		t.Errorf("Diagnostic[dropped_person] = %v, want %q", got, want)
+	}
+}